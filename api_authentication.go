@@ -0,0 +1,319 @@
+package application
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+)
+
+const (
+	// AuthAPIKey authenticates the requests with a static key sent on the X-API-Key header
+	AuthAPIKey = "API_KEY"
+	// AuthMTLS authenticates the requests with the client certificate presented on the TLS connection
+	AuthMTLS = "MTLS"
+	// AuthHMAC authenticates the requests with an HMAC-SHA256 signature of the timestamp, message headers and body
+	AuthHMAC = "HMAC"
+
+	apiKeyHeader      = "X-API-Key"      // Header with the API key
+	signatureHeader   = "X-Signature"    // Header with the hex encoded HMAC signature
+	timestampHeader   = "X-Timestamp"    // Header with the unix timestamp used on the signature
+	maxSignatureAge   = 5 * time.Minute  // Max difference between the request timestamp and the server time
+	maxAuthBodyLength = 10 * 1024 * 1024 // Max size of the body read to verify the signature
+	signaturePruning  = 1 * time.Minute  // Min time between the removals of expired signatures from the cache
+)
+
+// signedHeaders contains the headers of the message included on the signature, in the order they are signed
+var signedHeaders = []string{srvOrgID, "endpointName", "version", transmitterID, responseHeaders, requestPath, requestMethod}
+
+// Authenticator is the Interface that exposes the methods to authenticate inbound requests
+type Authenticator interface {
+	Authenticate(r *http.Request, body []byte) error // Returns an error if the request is not authenticated
+}
+
+// apiKeyAuthenticator authenticates the requests with static API keys
+type apiKeyAuthenticator struct {
+	keys []string // Allowed API keys
+}
+
+// Authenticate validates the API key of the request
+func (a *apiKeyAuthenticator) Authenticate(r *http.Request, _ []byte) error {
+	key := r.Header.Get(apiKeyHeader)
+	if key == "" {
+		return errors.New("api key not found")
+	}
+
+	for _, allowed := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(allowed)) == 1 {
+			return nil
+		}
+	}
+
+	return errors.New("api key not allowed")
+}
+
+// mtlsAuthenticator authenticates the requests with the client certificates of an allow list
+type mtlsAuthenticator struct {
+	allowList []string // Allowed certificate subjects (CN) or SHA-256 fingerprints
+}
+
+// Authenticate validates the client certificate of the request
+func (a *mtlsAuthenticator) Authenticate(r *http.Request, _ []byte) error {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return errors.New("verified client certificate not found")
+	}
+
+	certificate := r.TLS.VerifiedChains[0][0]
+	fingerprint := sha256.Sum256(certificate.Raw)
+	fingerprintHex := hex.EncodeToString(fingerprint[:])
+	for _, allowed := range a.allowList {
+		if allowed == certificate.Subject.CommonName || strings.EqualFold(strings.ReplaceAll(allowed, ":", ""), fingerprintHex) {
+			return nil
+		}
+	}
+
+	return errors.New("client certificate not allowed: " + certificate.Subject.CommonName)
+}
+
+// signatureCache stores the signatures already accepted, so replayed requests can be rejected
+type signatureCache struct {
+	mutex     sync.Mutex           // Mutex for thread-safe access to the signatures
+	seen      map[string]time.Time // Expiration of the accepted signatures, by timestamp and signature
+	lastPrune time.Time            // Last time the expired signatures were removed
+}
+
+// newSignatureCache creates an empty signature cache
+func newSignatureCache() *signatureCache {
+	return &signatureCache{seen: make(map[string]time.Time)}
+}
+
+// add registers an accepted signature, it is kept until its timestamp is out of the allowed window
+//
+// Parameters:
+//   - key: Timestamp and signature of the request
+//   - expiration: Date after which the timestamp is not accepted anymore
+//
+// Returns:
+//   - bool: false if the signature was already accepted
+func (c *signatureCache) add(key string, expiration time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.lastPrune) >= signaturePruning {
+		for seenKey, seenExpiration := range c.seen {
+			if now.After(seenExpiration) {
+				delete(c.seen, seenKey)
+			}
+		}
+
+		c.lastPrune = now
+	}
+
+	if _, found := c.seen[key]; found {
+		return false
+	}
+
+	c.seen[key] = expiration
+	return true
+}
+
+// hmacAuthenticator authenticates the requests with an HMAC-SHA256 signature
+type hmacAuthenticator struct {
+	secret     []byte          // Shared secret used to sign the requests
+	signatures *signatureCache // Signatures already accepted
+}
+
+// Authenticate validates the signature of the request, the signature is calculated over the canonical string of
+// the request, a signature can only be used once
+func (a *hmacAuthenticator) Authenticate(r *http.Request, body []byte) error {
+	timestamp := r.Header.Get(timestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("timestamp not found or bad format")
+	}
+
+	age := time.Since(time.Unix(seconds, 0))
+	if age > maxSignatureAge || age < -maxSignatureAge {
+		return errors.New("timestamp out of the allowed window")
+	}
+
+	signature, err := hex.DecodeString(r.Header.Get(signatureHeader))
+	if err != nil || len(signature) == 0 {
+		return errors.New("signature not found or bad format")
+	}
+
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(getCanonicalHeaders(timestamp, r.Header)))
+	mac.Write(body)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errors.New("invalid signature")
+	}
+
+	if !a.signatures.add(timestamp+"."+hex.EncodeToString(signature), time.Unix(seconds, 0).Add(maxSignatureAge)) {
+		return errors.New("signature already used")
+	}
+
+	return nil
+}
+
+// getCanonicalHeaders returns the part of the canonical string signed before the body, one line for the timestamp,
+// one "name:value" line for each of the signedHeaders (empty value if not sent), and one line for each
+// responseHeader- header, with its name in lower case and sorted by name
+//
+// Parameters:
+//   - timestamp: Value of the timestamp header
+//   - header: Headers of the request
+//
+// Returns:
+//   - string: Canonical headers, the body is appended after the last line
+func getCanonicalHeaders(timestamp string, header http.Header) string {
+	var builder strings.Builder
+	builder.WriteString(timestamp + "\n")
+	for _, name := range signedHeaders {
+		builder.WriteString(name + ":" + header.Get(name) + "\n")
+	}
+
+	prefixed := make([]string, 0)
+	for key, values := range header {
+		if len(values) > 0 && len(key) > len(responseHeaderPfx) && strings.EqualFold(key[:len(responseHeaderPfx)], responseHeaderPfx) {
+			prefixed = append(prefixed, strings.ToLower(key)+":"+values[0])
+		}
+	}
+
+	sort.Strings(prefixed)
+	for _, line := range prefixed {
+		builder.WriteString(line + "\n")
+	}
+
+	return builder.String()
+}
+
+// getAuthenticators returns the authenticators configured for a route
+//
+// Parameters:
+//   - route: Name of the route
+//
+// Returns:
+//   - map[string]Authenticator: authenticators by method, empty if the route does not require authentication
+func (as *APIServer) getAuthenticators(route string) map[string]Authenticator {
+	settings := as.cm.settings.InboundAuthSettings
+	result := make(map[string]Authenticator)
+	for _, method := range settings.Routes[route] {
+		switch strings.ToUpper(strings.TrimSpace(method)) {
+		case AuthAPIKey:
+			result[AuthAPIKey] = &apiKeyAuthenticator{keys: settings.APIKeys}
+		case AuthMTLS:
+			result[AuthMTLS] = &mtlsAuthenticator{allowList: settings.ClientCertificateAllowList}
+		case AuthHMAC:
+			result[AuthHMAC] = &hmacAuthenticator{secret: []byte(settings.HMACSecret), signatures: as.signatures}
+		default:
+			as.logger.Warning("Authentication method not supported: "+method, as.pack, "getAuthenticators")
+		}
+	}
+
+	return result
+}
+
+// authenticate wraps a handler so the requests are only served if any of the methods configured for the route succeeds
+//
+// Parameters:
+//   - route: Name of the route
+//   - next: Handler of the route
+//
+// Returns:
+//   - http.Handler: Handler with authentication
+func (as *APIServer) authenticate(route string, next http.Handler) http.Handler {
+	authenticators := as.getAuthenticators(route)
+	if len(authenticators) == 0 {
+		return next
+	}
+
+	_, needsBody := authenticators[AuthHMAC]
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if needsBody {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(r.Body, maxAuthBodyLength+1))
+			if err != nil {
+				as.updateResponseError(w, GenericError{Message: "Failed to read request body."}, http.StatusInternalServerError)
+				return
+			}
+
+			if len(body) > maxAuthBodyLength {
+				as.updateResponseError(w, GenericError{Message: "Request body too large."}, http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			// Restore the body for the route handler
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		for method, authenticator := range authenticators {
+			err := authenticator.Authenticate(r, body)
+			if err == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			as.logger.Debug(method+" authentication failed: "+err.Error(), as.pack, "authenticate")
+		}
+
+		monitoring.IncreaseRejectedAuthentications(route)
+		as.updateResponseError(w, GenericError{Message: "Unauthorized."}, http.StatusUnauthorized)
+	})
+}
+
+// getServerTLSConfig returns the TLS configuration of the server, client certificates are requested when mTLS authentication is configured
+//
+// Parameters:
+//
+// Returns:
+//   - *tls.Config: TLS configuration, nil if mTLS authentication is not configured
+//   - error: error if the client CA bundle could not be loaded
+func (as *APIServer) getServerTLSConfig() (*tls.Config, error) {
+	settings := as.cm.settings.InboundAuthSettings
+	mtlsConfigured := false
+	for _, methods := range settings.Routes {
+		for _, method := range methods {
+			if strings.EqualFold(strings.TrimSpace(method), AuthMTLS) {
+				mtlsConfigured = true
+			}
+		}
+	}
+
+	if !mtlsConfigured {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(settings.ClientCAFilePath))
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificates found in client CA bundle: " + settings.ClientCAFilePath)
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  pool,
+	}, nil
+}
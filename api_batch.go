@@ -0,0 +1,198 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+)
+
+const (
+	maxBatchItems        = 1000                   // Max number of messages accepted in a batch request
+	ndjsonContentType    = "application/x-ndjson" // Content type for the NDJSON batches
+	jsonLinesContentType = "application/jsonl"    // Alternative content type for the NDJSON batches
+)
+
+// BatchItem contains a message of a batch request, with the same values sent as headers on /ValidateResponse
+type BatchItem struct {
+	ServerOrgID        string            `json:"serverOrgId"`               // Server organization ID
+	XFapiInteractionID string            `json:"x-fapi-interaction-id"`     // Interaction ID of the response
+	EndpointName       string            `json:"endpointName"`              // Endpoint name
+	Version            string            `json:"version"`                   // Version of the API
+	ConsentID          string            `json:"consentID"`                 // Consent ID
+	TransmitterID      string            `json:"transmitterID"`             // Transmitter ID
+	RequestPath        string            `json:"requestPath,omitempty"`     // Path of the original request, used if endpointName is empty
+	HTTPMethod         string            `json:"httpMethod,omitempty"`      // HTTP method of the original request
+	ResponseHeaders    map[string]string `json:"responseHeaders,omitempty"` // Headers of the original response
+	Body               json.RawMessage   `json:"body"`                      // Body of the original response
+}
+
+// BatchItemResult contains the result of a message of a batch request
+type BatchItemResult struct {
+	Index              int    `json:"index"`                 // Position of the message in the batch
+	XFapiInteractionID string `json:"x-fapi-interaction-id"` // Interaction ID of the message
+	Accepted           bool   `json:"accepted"`              // Indicates if the message was accepted
+	Message            string `json:"message,omitempty"`     // Reason of the rejection
+	RetryAfter         int    `json:"retryAfter,omitempty"`  // Seconds to wait before sending the message again, when the queue is full
+}
+
+// BatchResult contains the results of a batch request
+type BatchResult struct {
+	Accepted int               `json:"accepted"` // Number of messages accepted
+	Rejected int               `json:"rejected"` // Number of messages rejected
+	Results  []BatchItemResult `json:"results"`  // Result by message
+}
+
+// getHeader returns the values of the item as headers, so they are validated the same way as /ValidateResponse
+//
+// Returns:
+//   - http.Header: headers with the values of the item
+//   - error: error if the response headers could not be serialized
+func (bi *BatchItem) getHeader() (http.Header, error) {
+	header := http.Header{}
+	header.Set(srvOrgID, bi.ServerOrgID)
+	header.Set(xFAPIInteractionID, bi.XFapiInteractionID)
+	header.Set("endpointName", bi.EndpointName)
+	header.Set("version", bi.Version)
+	header.Set("consentID", bi.ConsentID)
+	header.Set(transmitterID, bi.TransmitterID)
+	header.Set(requestPath, bi.RequestPath)
+	header.Set(requestMethod, bi.HTTPMethod)
+	if len(bi.ResponseHeaders) > 0 {
+		headers, err := json.Marshal(bi.ResponseHeaders)
+		if err != nil {
+			return nil, err
+		}
+
+		header.Set(responseHeaders, string(headers))
+	}
+
+	return header, nil
+}
+
+// handleValidateResponsesBatch Handles batch requests, the body can be a JSON array or NDJSON (one message per line).
+// The whole batch is decoded before the messages are enqueued, so no message is enqueued if the batch is rejected
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (as *APIServer) handleValidateResponsesBatch(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isNDJSON := mediaType == ndjsonContentType || mediaType == jsonLinesContentType
+
+	decoder := json.NewDecoder(r.Body)
+	if !isNDJSON {
+		token, err := decoder.Token()
+		if delim, ok := token.(json.Delim); err != nil || !ok || delim != '[' {
+			monitoring.IncreaseBadRequestsReceived()
+			as.updateResponseError(w, GenericError{Message: "body: Not a Valid JSON Array."}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	items := make([]BatchItem, 0)
+	for index := 0; isNDJSON || decoder.More(); index++ {
+		var item BatchItem
+		err := decoder.Decode(&item)
+		if isNDJSON && errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			// The rest of the stream can not be read after a syntax error
+			monitoring.IncreaseBadRequestsReceived()
+			as.updateResponseError(w, GenericError{Message: "body: Not a Valid JSON Message at position " + strconv.Itoa(index) + "."}, http.StatusBadRequest)
+			return
+		}
+
+		if index >= maxBatchItems {
+			monitoring.IncreaseBadRequestsReceived()
+			as.updateResponseError(w, GenericError{Message: "body: Max number of messages exceeded (" + strconv.Itoa(maxBatchItems) + ")."}, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		items = append(items, item)
+	}
+
+	if !isNDJSON {
+		// A truncated array is rejected, the messages read may not be the whole batch
+		token, err := decoder.Token()
+		if delim, ok := token.(json.Delim); err != nil || !ok || delim != ']' {
+			monitoring.IncreaseBadRequestsReceived()
+			as.updateResponseError(w, GenericError{Message: "body: Not a Valid JSON Array."}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	result := BatchResult{Results: make([]BatchItemResult, 0, len(items))}
+	for index := range items {
+		itemResult := as.processBatchItem(&items[index], r.Method)
+		itemResult.Index = index
+		if itemResult.Accepted {
+			result.Accepted++
+		} else {
+			result.Rejected++
+		}
+
+		result.Results = append(result.Results, itemResult)
+	}
+
+	monitoring.RecordResponseDuration(startTime)
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(result)
+	if err != nil {
+		as.logger.Error(err, "Error writing response:", as.pack, "handleValidateResponsesBatch")
+	}
+}
+
+// processBatchItem validates the values of a batch message and enqueues it for processing
+//
+// Parameters:
+//   - item: Message of the batch
+//   - httpMethod: HTTP method of the batch request
+//
+// Returns:
+//   - BatchItemResult: Result of the message
+func (as *APIServer) processBatchItem(item *BatchItem, httpMethod string) BatchItemResult {
+	monitoring.IncreaseRequestsReceived()
+	itemResult := BatchItemResult{XFapiInteractionID: item.XFapiInteractionID}
+
+	header, err := item.getHeader()
+	if err != nil {
+		monitoring.IncreaseBadRequestsReceived()
+		itemResult.Message = responseHeaders + ": Not a Valid JSON Message."
+		return itemResult
+	}
+
+	var msg Message
+	loadError := as.loadMessageHeaderValues(header, &msg)
+	if loadError != nil {
+		itemResult.Message = loadError.Message
+		return itemResult
+	}
+
+	if msg.HTTPMethod == "" {
+		msg.HTTPMethod = httpMethod
+	}
+
+	processError, responseCode := as.enqueueValidMessage(&msg, item.Body)
+	if processError != nil {
+		itemResult.Message = processError.Message
+		if responseCode == http.StatusServiceUnavailable {
+			itemResult.RetryAfter = as.cm.GetQueueRetryAfter()
+		}
+
+		return itemResult
+	}
+
+	itemResult.Accepted = true
+	return itemResult
+}
@@ -24,8 +24,9 @@ type APISetting struct {
 
 // APIEndpointSetting has the specific validation settings for an endpoint
 type APIEndpointSetting struct {
-	Endpoint              string `json:"endpoint"`                // Name of the endpoint requested
-	HeaderValidationRules string `json:"header_validation_rules"` // Header validation rules
+	Endpoint              string `json:"endpoint"`                // Name of the endpoint requested, path parameters are written as {consentId}
+	Method                string `json:"method"`                  // HTTP method of the endpoint, empty if the settings apply to any method
+	HeaderValidationRules string `json:"header_validation_rules"` // Header validation rules, JSON object with a validation.HeaderRule by header name
 	BodyValidationRules   string `json:"body_validation_rules"`   // Body validation rules
 	JSONHeaderSchema      string `json:"header_schema"`           // Schema for the Header
 	JSONBodySchema        string `json:"body_schema"`             // JSON schema for the Body
@@ -36,7 +37,7 @@ type APIEndpointSetting struct {
 type APIGroupSetting struct {
 	Group    string       `json:"group"`     // API Group name
 	BasePath string       `json:"base_path"` // Base path for the folder
-	APIList  []APISetting `json:"api_list"`  // List of APIs
+	APIList  []APISetting `json:"api_list"`  // List of APIs, an API can be listed once by version
 }
 
 // ValidationSettings stores the configuration for validations of the application
@@ -84,3 +85,39 @@ func (vs *APIGroupSetting) GetAPISetting(apiName string) *APISetting {
 
 	return nil
 }
+
+// GetAPIVersionSetting returns the settings of a specific version of an API
+//
+// Parameters:
+//   - apiName: API name to find the settings
+//   - version: Version of the API
+//
+// Returns:
+//   - *APISetting: API settings found, nil if the version is not supported
+func (vs *APIGroupSetting) GetAPIVersionSetting(apiName string, version string) *APISetting {
+	for i, setting := range vs.APIList {
+		if setting.API == apiName && setting.Version == version {
+			return &vs.APIList[i]
+		}
+	}
+
+	return nil
+}
+
+// GetAPIVersions returns the versions configured for an API
+//
+// Parameters:
+//   - apiName: API name to find the versions
+//
+// Returns:
+//   - []string: Versions of the API, in the configuration order
+func (vs *APIGroupSetting) GetAPIVersions(apiName string) []string {
+	versions := make([]string, 0)
+	for _, setting := range vs.APIList {
+		if setting.API == apiName {
+			versions = append(versions, setting.Version)
+		}
+	}
+
+	return versions
+}
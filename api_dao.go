@@ -1,12 +1,15 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
@@ -16,40 +19,71 @@ import (
 
 // RestAPI is the struct to handle connections to APIs
 type RestAPI struct {
-	crosscutting.OFBStruct               // Base structure
-	token                  *jwt.JWKToken // Token used by the server
+	crosscutting.OFBStruct                   // Base structure
+	tokenManager           *jwt.TokenManager // Manager of the tokens used on the requests to the server
 	serverURL              string
+	certificates           *ClientCertificates  // Client certificates for mTLS, nil if not configured
+	clientAuth             ClientAuthentication // Authentication of the client on the token request
+	timeouts               HTTPTimeouts         // Timeouts for the requests to the server
+	httpClient             *http.Client         // Client shared by all the requests, created on first use
+	httpClientOnce         sync.Once            // Guarantees the client is created only once
 }
 
-// loadCertificates Loads certificates from environment variables
+// HTTPTimeouts stores the timeouts used for the requests to the server
+type HTTPTimeouts struct {
+	Connect time.Duration // Max time to establish the connection, including the TLS handshake
+	Read    time.Duration // Max time to wait for the response headers once the request was sent
+	Request time.Duration // Max time for the whole request, including reading the body
+}
+
+// requestNewJWTToken requests a new token to the server, a refresh token grant is used when refreshToken is not empty
 // @author AB
 // @params
 // @return
 // error: Error if any
 // Response from server in case of success
-func (ad *RestAPI) requestNewJWTToken(clientID string) (*jwt.JWKToken, error) {
+func (ad *RestAPI) requestNewJWTToken(ctx context.Context, clientID string, refreshToken string) (*jwt.JWKToken, error) {
 	ad.Logger.Info("Requesting new token", ad.Pack, "requestNewJWTToken")
 
 	// Create an HTTP client
-	client := &http.Client{}
+	client := ad.getHTTPClient()
 
 	// Define the parameters for the token request
 	params := url.Values{}
 	params.Set("grant_type", "client_credentials")
-	params.Set("client_id", clientID)
+	if refreshToken != "" {
+		params.Set("grant_type", "refresh_token")
+		params.Set("refresh_token", refreshToken)
+	}
+
+	clientAuth := ad.clientAuth
+	if clientAuth == nil {
+		clientAuth = &noneClientAuthentication{}
+	}
+
+	header := http.Header{}
+	err := clientAuth.Apply(params, header, clientID, ad.serverURL+tokenPath)
+	if err != nil {
+		ad.Logger.Error(err, "Error creating client authentication", ad.Pack, "requestNewJWTToken")
+		return nil, err
+	}
+
 	requestBody := params.Encode()
 
 	ad.Logger.Debug("ServerURL:"+ad.serverURL+tokenPath, ad.Pack, "requestNewJWTToken")
-	ad.Logger.Debug("Body:"+requestBody, ad.Pack, "requestNewJWTToken")
 
 	// Create a new HTTP request
-	req, err := http.NewRequest("POST", ad.serverURL+tokenPath, strings.NewReader(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", ad.serverURL+tokenPath, strings.NewReader(requestBody))
 	if err != nil {
 		ad.Logger.Error(err, "Error creating request", ad.Pack, "requestNewJWTToken")
 		return nil, err
 	}
 
-	// Set the content type header
+	// Set the content type and client authentication headers
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// Send the request
@@ -71,7 +105,10 @@ func (ad *RestAPI) requestNewJWTToken(clientID string) (*jwt.JWKToken, error) {
 		if err != nil {
 			return nil, err
 		}
-		result, _ = jwt.GetTokenFromBinary(ad.Logger, bodyBytes)
+		result, err = jwt.GetTokenFromBinary(ad.Logger, bodyBytes)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		ad.Logger.Warning("Request failed with status code: "+strconv.Itoa(response.StatusCode), ad.Pack, "requestNewJWTToken")
 		if ad.Logger.GetLoggingGlobalLevel() == log.DebugLevel {
@@ -85,67 +122,129 @@ func (ad *RestAPI) requestNewJWTToken(clientID string) (*jwt.JWKToken, error) {
 	return result, nil
 }
 
+// initTokenManager creates the manager of the tokens requested for the specified client
+//
+// Parameters:
+//   - clientID: Client identifier used to request the tokens
+//   - refreshFraction: Fraction of the token lifetime after which the token is refreshed
+//   - clockSkew: Tolerance for differences between the client and server clocks
+//
+// Returns:
+func (ad *RestAPI) initTokenManager(clientID string, refreshFraction float64, clockSkew time.Duration) {
+	ad.tokenManager = jwt.NewTokenManager(ad.Logger, func(ctx context.Context, refreshToken string) (*jwt.JWKToken, error) {
+		return ad.requestNewJWTToken(ctx, clientID, refreshToken)
+	}, refreshFraction, clockSkew)
+}
+
 // getJWKToken returns a valid Token to be used in a secure communication
 // @author AB
 // @params
 // @return
+// Token to be used
 // Error if any
-func (ad *RestAPI) getJWKToken(clientID string) error {
+func (ad *RestAPI) getJWKToken(ctx context.Context) (*jwt.JWKToken, error) {
 	ad.Logger.Info("Loading JWT token", ad.Pack, "getJWKToken")
 
-	if ad.token != nil && jwt.ValidateExpiration(ad.Logger, ad.token) {
-		ad.Logger.Info("Token is valid, using previous token", ad.Pack, "getJWKToken")
-		return nil
-	}
-
-	ad.Logger.Info("Token is invalid, Requesting new token", ad.Pack, "getJWKToken")
-
-	token, err := ad.requestNewJWTToken(clientID)
+	token, err := ad.tokenManager.GetToken(ctx)
 	if err != nil {
 		ad.Logger.Error(err, "Error sending request", ad.Pack, "getJWKToken")
-		return err
+		return nil, err
 	}
 
-	ad.token = token
-	return nil
+	return token, nil
 }
 
-// getHTTPClient Returns a client configured to use certificates for mTLS communication
+// refreshJWKToken discards the current token and requests a new one, used when the server rejects the current token
+//
+// Parameters:
+//   - ctx: Context of the request
+//
+// Returns:
+//   - *jwt.JWKToken: New token
+//   - error: Error if any
+func (ad *RestAPI) refreshJWKToken(ctx context.Context) (*jwt.JWKToken, error) {
+	ad.tokenManager.Invalidate()
+	return ad.getJWKToken(ctx)
+}
+
+// getHTTPClient Returns the client shared by all the requests, configured with timeouts, keep-alive pooling,
+// proxy from the HTTP_PROXY / HTTPS_PROXY environment variables and certificates for mTLS communication
 // @author AB
 // @return
 // http client: Client created with certificate info
 func (ad *RestAPI) getHTTPClient() *http.Client {
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			// TLSClientConfig: &tls.Config{
-			// 	Certificates:       []tls.Certificate{ad.certificates},
-			// 	InsecureSkipVerify: true,
-			// },
-		},
-	}
+	ad.httpClientOnce.Do(func() {
+		timeouts := ad.timeouts
+		if timeouts.Connect <= 0 {
+			timeouts.Connect = 10 * time.Second
+		}
 
-	return httpClient
+		if timeouts.Read <= 0 {
+			timeouts.Read = 30 * time.Second
+		}
+
+		if timeouts.Request <= 0 {
+			timeouts.Request = 60 * time.Second
+		}
+
+		transport := &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   timeouts.Connect,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          10,
+			MaxIdleConnsPerHost:   10,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   timeouts.Connect,
+			ResponseHeaderTimeout: timeouts.Read,
+			ExpectContinueTimeout: 1 * time.Second,
+		}
+
+		if ad.certificates != nil {
+			transport.TLSClientConfig = ad.certificates.GetTLSConfig()
+		}
+
+		ad.httpClient = &http.Client{
+			Transport: transport,
+			Timeout:   timeouts.Request,
+		}
+	})
+
+	return ad.httpClient
 }
 
 // executeGet returns the response body of a GET request
-func (ad *RestAPI) executeGet(url string, retryTimes int) ([]byte, error) {
+func (ad *RestAPI) executeGet(ctx context.Context, url string, retryTimes int) ([]byte, error) {
 	ad.Logger.Info("Executing Get Request", ad.Pack, "executeGet")
 	ad.Logger.Debug("URL: "+url, ad.Pack, "executeGet")
 	httpClient := ad.getHTTPClient()
 
 	// Create a new request
-	response, err := httpClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		ad.Logger.Error(err, "Error creating request", ad.Pack, "executeGet")
+		return nil, err
+	}
+
+	response, err := httpClient.Do(req)
 	if err != nil {
 		ad.Logger.Error(err, "Error executing request", ad.Pack, "executeGet")
-		if retryTimes > 0 {
+		if retryTimes > 0 && ad.waitForRetry(ctx) {
 			ad.Logger.Info("Retrying request", ad.Pack, "executeGet")
-			time.Sleep(1 * time.Second)
-			return ad.executeGet(url, retryTimes-1)
+			return ad.executeGet(ctx, url, retryTimes-1)
 		}
 
 		return nil, err
 	}
 
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			ad.Logger.Error(err, "Error closing response body", ad.Pack, "executeGet")
+		}
+	}()
+
 	if response.StatusCode == http.StatusForbidden {
 		ad.Logger.Warning("Forbidden status code", ad.Pack, "executeGet")
 		return nil, errors.New("forbidden status code")
@@ -154,22 +253,13 @@ func (ad *RestAPI) executeGet(url string, retryTimes int) ([]byte, error) {
 	// Check the status code of the response
 	if response.StatusCode != http.StatusOK {
 		ad.Logger.Warning("Unexpected status code: "+http.StatusText(response.StatusCode), ad.Pack, "executeGet")
-		if retryTimes > 0 {
+		if retryTimes > 0 && ad.waitForRetry(ctx) {
 			ad.Logger.Info("Retrying request", ad.Pack, "executeGet")
-			time.Sleep(1 * time.Second)
-			return ad.executeGet(url, retryTimes-1)
+			return ad.executeGet(ctx, url, retryTimes-1)
 		}
 		return nil, errors.New("invalid status code: " + strconv.Itoa(response.StatusCode))
 	}
 
-	defer func() {
-		if err := response.Body.Close(); err != nil {
-			ad.Logger.Error(err, "Error closing response body", ad.Pack, "executeGet")
-		}
-	}()
-
-	// defer response.Body.Close()
-
 	// Read the response body
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
@@ -185,3 +275,19 @@ func (ad *RestAPI) executeGet(url string, retryTimes int) ([]byte, error) {
 
 	return body, nil
 }
+
+// waitForRetry waits before retrying a request
+//
+// Parameters:
+//   - ctx: Context of the request
+//
+// Returns:
+//   - bool: false if the context was cancelled while waiting
+func (ad *RestAPI) waitForRetry(ctx context.Context) bool {
+	select {
+	case <-time.After(1 * time.Second):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
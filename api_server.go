@@ -1,12 +1,15 @@
 package application
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,20 +24,49 @@ const (
 	xFAPIInteractionID = "x-fapi-interaction-id"
 	srvOrgID           = "serverOrgId"
 	transmitterID      = "transmitterID"
+	responseHeaders    = "responseHeaders" // Header with the original response headers as a JSON object
+	responseHeaderPfx  = "responseHeader-" // Prefix for the original response headers forwarded one by one
+	modeSync           = "sync"            // Value of the mode parameter to validate the message synchronously
+	requestPath        = "requestPath"     // Header with the path of the original request, used if endpointName is not sent
+	requestMethod      = "httpMethod"      // Header with the HTTP method of the original request
+	unresolvedEndpoint = "UNRESOLVED"      // Endpoint name used in the metrics for request paths not found
 )
 
+// supportedMethods contains the HTTP methods accepted in the httpMethod header
+var supportedMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
 // GenericError contains information message when error needs to be returned
 type GenericError struct {
 	Message string // Error message
 }
 
+// SyncValidationResult contains the result returned by the synchronous validation mode
+type SyncValidationResult struct {
+	XFapiInteractionID   string              `json:"x-fapi-interaction-id"` // Interaction ID of the message
+	Endpoint             string              `json:"endpointName"`          // Name of the endpoint
+	Version              string              `json:"version"`               // Version of the API used for the validation
+	Valid                bool                `json:"valid"`                 // Indicates the result of the validation
+	Errors               map[string][]string `json:"errors"`                // Details for the errors found during the validation
+	Recorded             bool                `json:"recorded"`              // Indicates if the result was added to the report
+	ConfigurationVersion string              `json:"configurationVersion"`  // Version of the configuration used for the validation
+}
+
 // APIServer Contains the APIServer
 type APIServer struct {
-	pack           string                // Package name
-	logger         log.Logger            // Logger to be used
-	metricsHandler http.Handler          // Handler for the metric endpoint
-	qm             *QueueManager         // Manager for the message queue
-	cm             *ConfigurationManager // Manager for application settings
+	pack           string                  // Package name
+	logger         log.Logger              // Logger to be used
+	metricsHandler http.Handler            // Handler for the metric endpoint
+	qm             *QueueManager           // Manager for the message queue
+	mpw            *MessageProcessorWorker // Message processor used for the synchronous validation
+	cm             *ConfigurationManager   // Manager for application settings
+	server         *http.Server            // HTTP server, nil until StartServing is called
+	signatures     *signatureCache         // Signatures accepted by the HMAC authentication of all the routes
 }
 
 // GetAPIServer Creates a new APIServer
@@ -44,16 +76,19 @@ type APIServer struct {
 //   - metricsHandler: Metric handler to expose \metrics
 //   - qm: Queue manager to queue the requests
 //   - cm: ConfigurationManager to handle the configuration
+//   - mpw: Message processor for the synchronous validation
 //
 // Returns:
 //   - *APIServer: APIServer created
-func GetAPIServer(logger log.Logger, metricsHandler http.Handler, qm *QueueManager, cm *ConfigurationManager) *APIServer {
+func GetAPIServer(logger log.Logger, metricsHandler http.Handler, qm *QueueManager, cm *ConfigurationManager, mpw *MessageProcessorWorker) *APIServer {
 	return &APIServer{
 		pack:           "API",
 		logger:         logger,
 		metricsHandler: metricsHandler,
 		qm:             qm,
 		cm:             cm,
+		mpw:            mpw,
+		signatures:     newSignatureCache(),
 	}
 }
 
@@ -63,30 +98,73 @@ func GetAPIServer(logger log.Logger, metricsHandler http.Handler, qm *QueueManag
 // Returns:
 func (as *APIServer) StartServing() {
 	r := mux.NewRouter()
-	r.Handle("/metrics", as.metricsHandler)
+	r.Handle("/metrics", as.authenticate("Metrics", as.metricsHandler))
 
 	// Validator for Responses
-	r.HandleFunc("/ValidateResponse", as.handleValidateResponseMessage).Name("ValidateResponse").Methods("POST")
+	r.Handle("/ValidateResponse", as.authenticate("ValidateResponse", http.HandlerFunc(as.handleValidateResponseMessage))).Name("ValidateResponse").Methods("POST")
+
+	// Batch validator for Responses
+	r.Handle("/ValidateResponses", as.authenticate("ValidateResponses", http.HandlerFunc(as.handleValidateResponsesBatch))).Name("ValidateResponses").Methods("POST")
+
+	// Audit log of the configuration changes, only exposed when the Admin route requires authentication
+	if len(as.getAuthenticators("Admin")) > 0 {
+		r.Handle("/admin/configuration/changes", as.authenticate("Admin", http.HandlerFunc(as.handleConfigurationChanges))).Name("ConfigurationChanges").Methods("GET")
+	} else {
+		as.logger.Info("Admin routes disabled, no authentication method configured for the Admin route", as.pack, "StartServing")
+	}
 
 	port := as.cm.settings.ConfigurationSettings.APIPort
 	// Remove ":" if found
 	port = strings.Replace(port, ":", "", -1)
 
-	server := &http.Server{
+	as.server = &http.Server{
 		Addr:         ":" + port,
 		Handler:      r,
 		ReadTimeout:  20 * time.Second,
 		WriteTimeout: 20 * time.Second,
 	}
 
+	tlsConfig, err := as.getServerTLSConfig()
+	if err != nil {
+		as.logger.Fatal(err, "Error loading client CA bundle", as.pack, "StartServing")
+	}
+
+	if tlsConfig != nil {
+		if !as.cm.IsHTTPS() {
+			as.logger.Warning("MTLS authentication requires EnableHTTPS, client certificates will not be requested", as.pack, "StartServing")
+		}
+
+		as.server.TLSConfig = tlsConfig
+	}
+
 	as.logger.Log("Starting the server on port "+port, as.pack, "StartServing")
 	if as.cm.IsHTTPS() {
-		as.logger.Fatal(server.ListenAndServeTLS(as.cm.GetCertFilePath(), as.cm.GetKeyFilePath()), "", as.pack, "StartServing")
+		err = as.server.ListenAndServeTLS(as.cm.GetCertFilePath(), as.cm.GetKeyFilePath())
 	} else {
-		as.logger.Fatal(server.ListenAndServe(), "", as.pack, "StartServing")
+		err = as.server.ListenAndServe()
+	}
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		as.logger.Fatal(err, "", as.pack, "StartServing")
 	}
 }
 
+// Shutdown stops accepting new requests and waits for the active requests to finish
+//
+// Parameters:
+//   - ctx: Context with the deadline for the shutdown
+//
+// Returns:
+//   - error: error if the active requests did not finish before the deadline
+func (as *APIServer) Shutdown(ctx context.Context) error {
+	if as.server == nil {
+		return nil
+	}
+
+	as.logger.Info("Stopping the server", as.pack, "Shutdown")
+	return as.server.Shutdown(ctx)
+}
+
 // updateResponseError Handles requests to the specified urls in the settings
 //
 // Parameters:
@@ -120,23 +198,15 @@ func (as *APIServer) updateResponseError(w http.ResponseWriter, genericError Gen
 // mustValidate indicates if the endpoint should be validated or not base on the validation rate configured
 //
 // Parameters:
+//   - msg: Message with the configuration snapshot captured
 //   - endpointSettings: Endpoint settings with the configuration information
 //
 // Returns:
 //   - bool: true if the endpoint should be validated
-func (as *APIServer) mustValidate(endpointSetting *models.APIEndpointSetting) bool {
-	value := as.getRandomNumber()
+func (as *APIServer) mustValidate(msg *Message, endpointSetting *models.APIEndpointSetting) bool {
 	switch endpointSetting.Throughput {
-	case models.ExtremelyHighTroughput:
-		return value < as.cm.ConfigurationSettings.ValidationSettings.ExtremelyHighTroughputValidationRate
-	case models.HighTroughput:
-		return value < as.cm.ConfigurationSettings.ValidationSettings.HighTroughputValidationRate
-	case models.MediumTroughput:
-		return value < as.cm.ConfigurationSettings.ValidationSettings.MediumTroughputValidationRate
-	case models.LowTroughput:
-		return value < as.cm.ConfigurationSettings.ValidationSettings.LowTroughputValidationRate
-	case models.VeryLowTroughput:
-		return value < as.cm.ConfigurationSettings.ValidationSettings.VeryLowTroughputValidationRate
+	case models.ExtremelyHighTroughput, models.HighTroughput, models.MediumTroughput, models.LowTroughput, models.VeryLowTroughput:
+		return as.getRandomNumber() < msg.snapshot.GetValidationRate(endpointSetting.Throughput)
 	}
 
 	return true
@@ -163,10 +233,18 @@ func (as *APIServer) getRandomNumber() int {
 	return number
 }
 
-func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message) *GenericError {
+// loadMessageHeaderValues loads and validates the message values sent as headers
+//
+// Parameters:
+//   - header: Headers of the request, or of the batch item
+//   - message: Message to be loaded
+//
+// Returns:
+//   - *GenericError: error if any of the values is missing or has a bad format
+func (as *APIServer) loadMessageHeaderValues(header http.Header, message *Message) *GenericError {
 	genericError := &GenericError{}
 	// Read the Server Organization ID from the header
-	serverOrgID := r.Header.Get(srvOrgID)
+	serverOrgID := header.Get(srvOrgID)
 	_, err := uuid.Parse(serverOrgID)
 	if err != nil {
 		monitoring.IncreaseBadRequestsReceived()
@@ -174,7 +252,7 @@ func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message)
 		return genericError
 	}
 
-	xFapiID := r.Header.Get(xFAPIInteractionID)
+	xFapiID := header.Get(xFAPIInteractionID)
 	_, err = uuid.Parse(xFapiID)
 	if err != nil {
 		monitoring.IncreaseBadRequestsReceived()
@@ -182,7 +260,7 @@ func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message)
 		return genericError
 	}
 
-	txServerID := r.Header.Get(transmitterID)
+	txServerID := header.Get(transmitterID)
 	if txServerID != "" {
 		_, err = uuid.Parse(txServerID)
 		if err != nil {
@@ -193,16 +271,42 @@ func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message)
 	}
 
 	// Read the Server Organization ID from the header
-	endpointName := r.Header.Get("endpointName")
+	endpointName := header.Get("endpointName")
 
 	// Read the api version from the header
-	versionHeader := r.Header.Get("version")
+	versionHeader := header.Get("version")
 
 	// Read the api version from the header
-	consentID := r.Header.Get("consentID")
+	consentID := header.Get("consentID")
+
+	// Read the path and method of the original request, the endpoint is resolved with them if endpointName is not sent
+	path := header.Get(requestPath)
+	method := strings.ToUpper(strings.TrimSpace(header.Get(requestMethod)))
+	if method != "" && !supportedMethods[method] {
+		monitoring.IncreaseBadRequestsReceived()
+		genericError.Message = requestMethod + ": bad format."
+		return genericError
+	}
+
+	if endpointName == "" && path == "" {
+		monitoring.IncreaseBadRequestsReceived()
+		genericError.Message = "endpointName: Not found or bad format."
+		return genericError
+	}
+
+	headerMessage, err := as.loadResponseHeaders(header)
+	if err != nil {
+		monitoring.IncreaseBadRequestsReceived()
+		genericError.Message = responseHeaders + ": Not a Valid JSON Message."
+		return genericError
+	}
 
+	message.HeaderMessage = headerMessage
 	message.APIVersion = versionHeader
 	message.Endpoint = endpointName
+	message.RequestPath = path
+	message.RequestMethod = method
+	message.HTTPMethod = method
 	message.ServerID = serverOrgID
 	message.XFapiInteractionID = xFapiID
 	message.TransmitterID = txServerID
@@ -210,6 +314,202 @@ func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message)
 	return nil
 }
 
+// loadResponseHeaders loads the headers of the original response, they can be sent as a JSON object in the
+// responseHeaders header, or as a set of headers prefixed with responseHeader-
+//
+// Parameters:
+//   - header: Headers of the request, or of the batch item
+//
+// Returns:
+//   - string: JSON object with the original response headers, empty if no headers were sent
+//   - error: error if the headers are not a valid JSON object
+func (as *APIServer) loadResponseHeaders(header http.Header) (string, error) {
+	headers := make(map[string]string)
+	jsonHeaders := header.Get(responseHeaders)
+	if jsonHeaders != "" {
+		var values map[string]interface{}
+		err := json.Unmarshal([]byte(jsonHeaders), &values)
+		if err != nil {
+			return "", err
+		}
+
+		for key, value := range values {
+			switch v := value.(type) {
+			case string:
+				headers[strings.ToLower(key)] = v
+			case []interface{}:
+				// Only the first value of a multi value header is validated
+				if len(v) > 0 {
+					headers[strings.ToLower(key)] = fmt.Sprint(v[0])
+				}
+			default:
+				headers[strings.ToLower(key)] = fmt.Sprint(v)
+			}
+		}
+	}
+
+	for key, values := range header {
+		if len(values) == 0 || len(key) <= len(responseHeaderPfx) || !strings.EqualFold(key[:len(responseHeaderPfx)], responseHeaderPfx) {
+			continue
+		}
+
+		headers[strings.ToLower(key[len(responseHeaderPfx):])] = values[0]
+	}
+
+	if len(headers) == 0 {
+		return "", nil
+	}
+
+	result, err := json.Marshal(headers)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// checkMessage validates the body is a JSON message and the endpoint and version of the message are supported
+//
+// Parameters:
+//   - msg: Message with the header values loaded
+//   - body: Body of the message
+//
+// Returns:
+//   - *APIValidationSettings: Validation settings of the endpoint
+//   - *GenericError: error if the message is not valid
+func (as *APIServer) checkMessage(msg *Message, body []byte) (*APIValidationSettings, *GenericError) {
+	var js json.RawMessage
+	validJSON := json.Unmarshal(body, &js) == nil
+	if !validJSON {
+		monitoring.IncreaseBadRequestsReceived()
+		return nil, &GenericError{Message: "body: Not a Valid JSON Message."}
+	}
+
+	// Validate the endpoint configuration exists, the message is processed with the configuration captured here
+	validationSettings := as.cm.getValidationSettings(msg)
+	if validationSettings != nil {
+		return validationSettings, nil
+	}
+
+	if anyVersion := as.cm.getEndpointAnyVersion(msg); anyVersion != nil {
+		monitoring.IncreaseBadEndpointsReceived(anyVersion.EndpointName, msg.APIVersion, "Version not supported")
+		return nil, &GenericError{Message: "version: not supported for endpoint: " + anyVersion.EndpointName}
+	} else if msg.Endpoint == "" {
+		monitoring.IncreaseBadEndpointsReceived(unresolvedEndpoint, "N.A.", "Request path not supported")
+		return nil, &GenericError{Message: requestPath + ": not supported for " + requestMethod + ": " + msg.RequestMethod}
+	}
+
+	monitoring.IncreaseBadEndpointsReceived(msg.Endpoint, "N.A.", "Endpoint not supported")
+	return nil, &GenericError{Message: "endpointName: Not found or bad format."}
+}
+
+// enqueueValidMessage validates the body, endpoint and version of a message with loaded header values,
+// and enqueues it for processing if it is selected by the validation rate
+//
+// Parameters:
+//   - msg: Message with the header values loaded
+//   - body: Body of the message
+//
+// Returns:
+//   - *GenericError: error if the message was not accepted
+//   - int: HTTP response code for the error
+func (as *APIServer) enqueueValidMessage(msg *Message, body []byte) (*GenericError, int) {
+	validationSettings, checkError := as.checkMessage(msg, body)
+	if checkError != nil {
+		return checkError, http.StatusBadRequest
+	}
+
+	if as.mustValidate(msg, validationSettings.EndpointSettings) {
+		msg.Message = string(body)
+
+		// Enqueue the message for processing using worker's enqueueMessage
+		err := as.qm.EnqueueMessage(msg)
+		if err != nil {
+			as.logger.Warning("Message discarded, the queue is full", as.pack, "enqueueValidMessage")
+			if as.qm.IsRejectPolicy() {
+				return &GenericError{Message: "Queue is full, please retry later."}, http.StatusServiceUnavailable
+			}
+		}
+	}
+
+	return nil, http.StatusOK
+}
+
+// validateMessageSync validates the message inline, bypassing the validation rate, and writes the result as JSON.
+// The result is only added to the report when the record parameter is true
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//   - msg: Message with the header values loaded
+//   - body: Body of the message
+//
+// Returns:
+func (as *APIServer) validateMessageSync(w http.ResponseWriter, r *http.Request, msg *Message, body []byte) {
+	validationSettings, checkError := as.checkMessage(msg, body)
+	if checkError != nil {
+		as.updateResponseError(w, *checkError, http.StatusBadRequest)
+		return
+	}
+
+	record, _ := strconv.ParseBool(r.URL.Query().Get("record"))
+	msg.Message = string(body)
+	messageResult, err := as.mpw.ValidateMessageSync(msg, record)
+	if err != nil {
+		as.updateResponseError(w, GenericError{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	result := SyncValidationResult{
+		XFapiInteractionID:   msg.XFapiInteractionID,
+		Endpoint:             msg.Endpoint,
+		Version:              validationSettings.APIVersion,
+		Valid:                messageResult.Result,
+		Errors:               messageResult.Errors,
+		Recorded:             record,
+		ConfigurationVersion: messageResult.ConfigurationVersion,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		as.logger.Error(err, "Error writing JSON response:", as.pack, "validateMessageSync")
+	}
+}
+
+// handleConfigurationChanges returns the last configuration changes registered in the audit log,
+// the number of entries can be limited with the "limit" query parameter
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (as *APIServer) handleConfigurationChanges(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			as.updateResponseError(w, GenericError{Message: "limit: must be a positive number."}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	changes, err := as.cm.GetConfigurationChanges(limit)
+	if err != nil {
+		as.logger.Error(err, "Error reading configuration audit log", as.pack, "handleConfigurationChanges")
+		as.updateResponseError(w, GenericError{Message: "Error reading configuration audit log."}, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(changes)
+	if err != nil {
+		as.logger.Error(err, "Error writing JSON response:", as.pack, "handleConfigurationChanges")
+	}
+}
+
 // handleValidateResponseMessage Handles requests to the specified urls in the settings
 //
 // Parameters:
@@ -223,7 +523,7 @@ func (as *APIServer) handleValidateResponseMessage(w http.ResponseWriter, r *htt
 	monitoring.IncreaseRequestsReceived()
 	var msg Message
 
-	loadError := as.loadMessageHeaderValues(r, &msg)
+	loadError := as.loadMessageHeaderValues(r.Header, &msg)
 	if loadError != nil {
 		as.updateResponseError(w, *loadError, http.StatusBadRequest)
 		return
@@ -237,36 +537,30 @@ func (as *APIServer) handleValidateResponseMessage(w http.ResponseWriter, r *htt
 		return
 	}
 
-	var js json.RawMessage
-	validJSON := json.Unmarshal(body, &js) == nil
-	if !validJSON {
-		monitoring.IncreaseBadRequestsReceived()
-		genericError.Message = "body: Not a Valid JSON Message."
-		as.updateResponseError(w, *genericError, http.StatusBadRequest)
-		return
+	if msg.HTTPMethod == "" {
+		msg.HTTPMethod = r.Method
 	}
 
-	// Validate the endpoint configuration exists
-	validationSettings := as.cm.GetEndpointSettingFromAPI(msg.Endpoint, as.logger)
+	if r.URL.Query().Get("mode") == modeSync {
+		if !as.cm.IsSyncValidationEnabled() {
+			monitoring.IncreaseBadRequestsReceived()
+			as.updateResponseError(w, GenericError{Message: "mode: Synchronous validation is not enabled."}, http.StatusBadRequest)
+			return
+		}
 
-	if validationSettings == nil {
-		monitoring.IncreaseBadEndpointsReceived(msg.Endpoint, "N.A.", "Endpoint not supported")
-		genericError.Message = "endpointName: Not found or bad format."
-		as.updateResponseError(w, *genericError, http.StatusBadRequest)
-		return
-	} else if msg.APIVersion != "" && msg.APIVersion != validationSettings.APIVersion {
-		monitoring.IncreaseBadEndpointsReceived(msg.Endpoint, msg.APIVersion, "Version not supported")
-		genericError.Message = "version: not supported for as endpoint: " + msg.Endpoint
-		as.updateResponseError(w, *genericError, http.StatusBadRequest)
+		as.validateMessageSync(w, r, &msg, body)
+		monitoring.RecordResponseDuration(startTime)
 		return
 	}
 
-	if as.mustValidate(validationSettings.EndpointSettings) {
-		msg.Message = string(body)
-		msg.HTTPMethod = r.Method
+	processError, responseCode := as.enqueueValidMessage(&msg, body)
+	if processError != nil {
+		if responseCode == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", strconv.Itoa(as.cm.GetQueueRetryAfter()))
+		}
 
-		// Enqueue the message for processing using worker's enqueueMessage
-		as.qm.EnqueueMessage(&msg)
+		as.updateResponseError(w, *processError, responseCode)
+		return
 	}
 
 	monitoring.RecordResponseDuration(startTime)
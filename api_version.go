@@ -0,0 +1,144 @@
+package application
+
+import (
+	"strconv"
+	"strings"
+)
+
+// apiVersion is a parsed API version (major.minor.patch-prerelease), missing or wildcard components are nil
+type apiVersion struct {
+	components []*int // Numeric components, nil for wildcards (x, X, *)
+	prerelease string // Pre-release label, empty if none
+}
+
+// parseAPIVersion parses an API version or version range (2, 2.x, 2.1.*, v2.1.0, 2.1.0-rc.1)
+//
+// Parameters:
+//   - version: Version to parse
+//
+// Returns:
+//   - apiVersion: Parsed version
+//   - bool: false if the version is not valid
+func parseAPIVersion(version string) (apiVersion, bool) {
+	result := apiVersion{}
+	version = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+	if version == "" {
+		return result, false
+	}
+
+	if index := strings.IndexByte(version, '-'); index >= 0 {
+		result.prerelease = version[index+1:]
+		version = version[:index]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) > 3 {
+		return result, false
+	}
+
+	for _, part := range parts {
+		if part == "x" || part == "*" {
+			result.components = append(result.components, nil)
+			continue
+		}
+
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return result, false
+		}
+
+		result.components = append(result.components, &number)
+	}
+
+	return result, true
+}
+
+// isRange indicates if the version is a range, it has wildcards or less than 3 components
+//
+// Parameters:
+// Returns:
+//   - bool: true if the version is a range
+func (av apiVersion) isRange() bool {
+	if len(av.components) < 3 {
+		return true
+	}
+
+	for _, component := range av.components {
+		if component == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getComponent returns a numeric component of the version, missing components are 0
+//
+// Parameters:
+//   - index: Position of the component
+//
+// Returns:
+//   - int: Value of the component
+func (av apiVersion) getComponent(index int) int {
+	if index >= len(av.components) || av.components[index] == nil {
+		return 0
+	}
+
+	return *av.components[index]
+}
+
+// compareAPIVersions compares two versions, a version without pre-release is greater than the same version with it
+//
+// Parameters:
+//   - a: First version
+//   - b: Second version
+//
+// Returns:
+//   - int: -1 if a < b, 0 if they are equal, 1 if a > b
+func compareAPIVersions(a apiVersion, b apiVersion) int {
+	for i := 0; i < 3; i++ {
+		if a.getComponent(i) != b.getComponent(i) {
+			if a.getComponent(i) < b.getComponent(i) {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	switch {
+	case a.prerelease == b.prerelease:
+		return 0
+	case a.prerelease == "":
+		return 1
+	case b.prerelease == "":
+		return -1
+	case a.prerelease < b.prerelease:
+		return -1
+	}
+
+	return 1
+}
+
+// matchesAPIVersion indicates if a configured version satisfies the version requested. Exact versions must be equal,
+// ranges (2, 2.x, 2.1.*) match the components informed
+//
+// Parameters:
+//   - configured: Version configured for the API
+//   - requested: Version or range requested
+//
+// Returns:
+//   - bool: true if the configured version satisfies the requested one
+func matchesAPIVersion(configured apiVersion, requested apiVersion) bool {
+	if !requested.isRange() {
+		return compareAPIVersions(configured, requested) == 0
+	}
+
+	for i, component := range requested.components {
+		if component != nil && configured.getComponent(i) != *component {
+			return false
+		}
+	}
+
+	return requested.prerelease == "" || requested.prerelease == configured.prerelease
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// ClientAuthNone sends only the client_id on the token request
+	ClientAuthNone = "none"
+	// ClientAuthTLS authenticates the client with the certificate presented on the mTLS connection
+	ClientAuthTLS = "tls_client_auth"
+	// ClientAuthSecretBasic authenticates the client with the client secret on the Authorization header
+	ClientAuthSecretBasic = "client_secret_basic"
+	// ClientAuthSecretPost authenticates the client with the client secret on the request body
+	ClientAuthSecretPost = "client_secret_post"
+	// ClientAuthPrivateKeyJWT authenticates the client with a signed client assertion
+	ClientAuthPrivateKeyJWT = "private_key_jwt"
+
+	clientAssertionType     = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" // Type of the client assertion
+	clientAssertionLifetime = 5 * time.Minute                                          // Validity of the client assertion
+)
+
+// ClientAuthentication is the Interface that exposes the methods to authenticate the client on the token request
+type ClientAuthentication interface {
+	Apply(params url.Values, header http.Header, clientID string, tokenURL string) error // Includes the authentication parameters on the token request
+}
+
+// noneClientAuthentication only identifies the client, used when the authentication is done by a proxy
+type noneClientAuthentication struct{}
+
+// Apply includes the client_id on the token request
+func (ca *noneClientAuthentication) Apply(params url.Values, _ http.Header, clientID string, _ string) error {
+	params.Set("client_id", clientID)
+	return nil
+}
+
+// tlsClientAuthentication identifies the client, the authentication is done with the certificate of the mTLS connection
+type tlsClientAuthentication struct{}
+
+// Apply includes the client_id on the token request
+func (ca *tlsClientAuthentication) Apply(params url.Values, _ http.Header, clientID string, _ string) error {
+	params.Set("client_id", clientID)
+	return nil
+}
+
+// clientSecretBasicAuthentication authenticates the client with HTTP Basic authentication using the client secret
+type clientSecretBasicAuthentication struct {
+	secret string // Secret of the client
+}
+
+// Apply includes the client credentials on the Authorization header of the token request
+func (ca *clientSecretBasicAuthentication) Apply(_ url.Values, header http.Header, clientID string, _ string) error {
+	// The credentials are form encoded before the Basic encoding (RFC 6749, section 2.3.1)
+	credentials := url.QueryEscape(clientID) + ":" + url.QueryEscape(ca.secret)
+	header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	return nil
+}
+
+// clientSecretPostAuthentication authenticates the client with the client secret included on the request body
+type clientSecretPostAuthentication struct {
+	secret string // Secret of the client
+}
+
+// Apply includes the client credentials on the token request
+func (ca *clientSecretPostAuthentication) Apply(params url.Values, _ http.Header, clientID string, _ string) error {
+	params.Set("client_id", clientID)
+	params.Set("client_secret", ca.secret)
+	return nil
+}
+
+// privateKeyJWTAuthentication authenticates the client with a client assertion signed with the client private key
+type privateKeyJWTAuthentication struct {
+	signingMethod jwt.SigningMethod // Algorithm used to sign the assertion (PS256 / RS256)
+	key           interface{}       // Private key used to sign the assertion
+	keyID         string            // Identifier of the key, sent on the kid header
+	audience      string            // Audience of the assertion, the token URL is used if empty
+}
+
+// Apply includes the signed client assertion on the token request
+func (ca *privateKeyJWTAuthentication) Apply(params url.Values, _ http.Header, clientID string, tokenURL string) error {
+	audience := ca.audience
+	if audience == "" {
+		audience = tokenURL
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(ca.signingMethod, jwt.RegisteredClaims{
+		Issuer:    clientID,
+		Subject:   clientID,
+		Audience:  jwt.ClaimStrings{audience},
+		ID:        uuid.New().String(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(clientAssertionLifetime)),
+	})
+
+	if ca.keyID != "" {
+		token.Header["kid"] = ca.keyID
+	}
+
+	assertion, err := token.SignedString(ca.key)
+	if err != nil {
+		return err
+	}
+
+	params.Set("client_id", clientID)
+	params.Set("client_assertion_type", clientAssertionType)
+	params.Set("client_assertion", assertion)
+	return nil
+}
+
+// NewClientAuthentication creates the client authentication for the configured method
+//
+// Parameters:
+//   - method: Authentication method (none, tls_client_auth, client_secret_basic, client_secret_post, private_key_jwt)
+//   - secret: Secret of the client (client_secret_basic and client_secret_post only)
+//   - keyFile: Path of the PEM RSA private key used to sign the client assertion (private_key_jwt only)
+//   - keyID: Identifier of the key sent on the kid header (private_key_jwt only)
+//   - algorithm: Algorithm used to sign the client assertion, PS256 or RS256 (private_key_jwt only)
+//   - audience: Audience of the client assertion, the token URL is used if empty (private_key_jwt only)
+//
+// Returns:
+//   - ClientAuthentication: Client authentication for the method
+//   - error: error if the method is not supported or the key could not be loaded
+func NewClientAuthentication(method string, secret string, keyFile string, keyID string, algorithm string, audience string) (ClientAuthentication, error) {
+	switch method {
+	case "", ClientAuthNone:
+		return &noneClientAuthentication{}, nil
+	case ClientAuthTLS:
+		return &tlsClientAuthentication{}, nil
+	case ClientAuthSecretBasic, ClientAuthSecretPost:
+		if secret == "" {
+			return nil, errors.New("client secret is required for " + method)
+		}
+
+		if method == ClientAuthSecretBasic {
+			return &clientSecretBasicAuthentication{secret: secret}, nil
+		}
+
+		return &clientSecretPostAuthentication{secret: secret}, nil
+	case ClientAuthPrivateKeyJWT:
+		var signingMethod jwt.SigningMethod
+		switch algorithm {
+		case "", "PS256":
+			signingMethod = jwt.SigningMethodPS256
+		case "RS256":
+			signingMethod = jwt.SigningMethodRS256
+		default:
+			return nil, errors.New("client assertion algorithm not supported: " + algorithm)
+		}
+
+		data, err := os.ReadFile(filepath.Clean(keyFile))
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return &privateKeyJWTAuthentication{
+			signingMethod: signingMethod,
+			key:           key,
+			keyID:         keyID,
+			audience:      audience,
+		}, nil
+	}
+
+	return nil, errors.New("client authentication method not supported: " + method)
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "c5c6e8b2-0f4e-4d36-9a1c-7f1c2a5d1e3b" // Organisation id used as client id on the tests
+
+// newTestTokenServer starts a local token server that checks each token request with the function received
+func newTestTokenServer(t *testing.T, check func(t *testing.T, r *http.Request)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != tokenPath {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("invalid form: %v", err)
+		}
+
+		if r.PostForm.Get("grant_type") != "client_credentials" {
+			t.Errorf("unexpected grant_type: %s", r.PostForm.Get("grant_type"))
+		}
+
+		check(t, r)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":300}`))
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+// requestTestToken requests a token to the local token server using the client authentication received
+func requestTestToken(t *testing.T, serverURL string, clientAuth ClientAuthentication) {
+	t.Helper()
+	logger := log.GetLogger()
+	logger.SetLoggingGlobalLevel(log.ErrorLevel)
+	api := &RestAPI{
+		OFBStruct:  crosscutting.OFBStruct{Pack: "services.RestAPI", Logger: logger},
+		serverURL:  serverURL,
+		clientAuth: clientAuth,
+	}
+
+	token, err := api.requestNewJWTToken(context.Background(), testClientID, "")
+	if err != nil {
+		t.Fatalf("token request failed: %v", err)
+	}
+
+	if token.AccessToken != "token" {
+		t.Fatalf("unexpected access token: %s", token.AccessToken)
+	}
+}
+
+func TestClientSecretBasicAuthentication(t *testing.T) {
+	server := newTestTokenServer(t, func(t *testing.T, r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		if !ok {
+			t.Error("missing Authorization header")
+			return
+		}
+
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+		if clientID != testClientID || secret != "s3cr3t:+/" {
+			t.Errorf("unexpected credentials: %s / %s", clientID, secret)
+		}
+
+		if r.PostForm.Has("client_secret") {
+			t.Error("client_secret must not be sent on the body")
+		}
+	})
+
+	clientAuth, err := NewClientAuthentication(ClientAuthSecretBasic, "s3cr3t:+/", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestTestToken(t, server.URL, clientAuth)
+}
+
+func TestClientSecretPostAuthentication(t *testing.T) {
+	server := newTestTokenServer(t, func(t *testing.T, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Authorization header must not be sent")
+		}
+
+		if r.PostForm.Get("client_id") != testClientID || r.PostForm.Get("client_secret") != "s3cr3t" {
+			t.Errorf("unexpected credentials: %s / %s", r.PostForm.Get("client_id"), r.PostForm.Get("client_secret"))
+		}
+	})
+
+	clientAuth, err := NewClientAuthentication(ClientAuthSecretPost, "s3cr3t", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestTestToken(t, server.URL, clientAuth)
+}
+
+func TestClientSecretRequired(t *testing.T) {
+	for _, method := range []string{ClientAuthSecretBasic, ClientAuthSecretPost} {
+		if _, err := NewClientAuthentication(method, "", "", "", "", ""); err == nil {
+			t.Errorf("%s: expected error without client secret", method)
+		}
+	}
+}
+
+func TestPrivateKeyJWTAuthentication(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "client.key")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, algorithm := range []string{"PS256", "RS256"} {
+		t.Run(algorithm, func(t *testing.T) {
+			var serverURL string
+			ids := make(map[string]bool)
+			server := newTestTokenServer(t, func(t *testing.T, r *http.Request) {
+				if r.PostForm.Get("client_id") != testClientID {
+					t.Errorf("unexpected client_id: %s", r.PostForm.Get("client_id"))
+				}
+
+				if r.PostForm.Get("client_assertion_type") != clientAssertionType {
+					t.Errorf("unexpected client_assertion_type: %s", r.PostForm.Get("client_assertion_type"))
+				}
+
+				claims := &jwt.RegisteredClaims{}
+				token, err := jwt.ParseWithClaims(r.PostForm.Get("client_assertion"), claims, func(token *jwt.Token) (interface{}, error) {
+					return &key.PublicKey, nil
+				}, jwt.WithValidMethods([]string{algorithm}), jwt.WithAudience(serverURL+tokenPath), jwt.WithIssuer(testClientID), jwt.WithExpirationRequired())
+				if err != nil {
+					t.Errorf("invalid client assertion: %v", err)
+					return
+				}
+
+				if token.Header["kid"] != "key-1" {
+					t.Errorf("unexpected kid: %v", token.Header["kid"])
+				}
+
+				if claims.Subject != testClientID {
+					t.Errorf("unexpected sub: %s", claims.Subject)
+				}
+
+				if claims.ID == "" || ids[claims.ID] {
+					t.Errorf("jti must be unique: %s", claims.ID)
+				}
+
+				ids[claims.ID] = true
+				if lifetime := time.Until(claims.ExpiresAt.Time); lifetime <= 0 || lifetime > clientAssertionLifetime {
+					t.Errorf("unexpected exp: %v", claims.ExpiresAt.Time)
+				}
+			})
+			serverURL = server.URL
+
+			clientAuth, err := NewClientAuthentication(ClientAuthPrivateKeyJWT, "", keyFile, "key-1", algorithm, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			requestTestToken(t, server.URL, clientAuth)
+			requestTestToken(t, server.URL, clientAuth)
+			if len(ids) != 2 {
+				t.Errorf("expected 2 token requests, found %d", len(ids))
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	certificateReloadInterval = 1 * time.Minute // Time between the checks for rotated certificate files
+)
+
+// ClientCertificates loads the client certificate and CA bundle used for mutual TLS, the files are reloaded when they change
+type ClientCertificates struct {
+	crosscutting.OFBStruct
+	certFile       string           // Path of the PEM client certificate
+	keyFile        string           // Path of the PEM client key
+	pkcs12File     string           // Path of the PKCS#12 bundle, used instead of certFile / keyFile when present
+	pkcs12Password string           // Password of the PKCS#12 bundle
+	caFile         string           // Path of the PEM CA bundle used to verify the server
+	mutex          sync.RWMutex     // Mutex for thread-safe access to the certificates
+	certificate    *tls.Certificate // Client certificate loaded
+	rootCAs        *x509.CertPool   // CA bundle loaded, nil to use the system CAs
+	modTimes       map[string]time.Time
+	lastCheck      time.Time // Last time the files were checked for changes
+}
+
+// NewClientCertificates creates and loads the client certificates
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - certFile: Path of the PEM client certificate
+//   - keyFile: Path of the PEM client key
+//   - pkcs12File: Path of the PKCS#12 bundle, used instead of certFile / keyFile when present
+//   - pkcs12Password: Password of the PKCS#12 bundle
+//   - caFile: Path of the PEM CA bundle used to verify the server, system CAs are used if empty
+//
+// Returns:
+//   - *ClientCertificates: Client certificates loaded
+//   - error: error if the certificates could not be loaded
+func NewClientCertificates(logger log.Logger, certFile string, keyFile string, pkcs12File string, pkcs12Password string, caFile string) (*ClientCertificates, error) {
+	result := &ClientCertificates{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "services.ClientCertificates",
+			Logger: logger,
+		},
+		certFile:       certFile,
+		keyFile:        keyFile,
+		pkcs12File:     pkcs12File,
+		pkcs12Password: pkcs12Password,
+		caFile:         caFile,
+		modTimes:       make(map[string]time.Time),
+	}
+
+	err := result.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetTLSConfig returns a TLS configuration that presents the client certificate and verifies the server with the CA bundle
+//
+// Parameters:
+//
+// Returns:
+//   - *tls.Config: TLS configuration
+func (cc *ClientCertificates) GetTLSConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		GetClientCertificate: cc.getClientCertificate,
+	}
+
+	if cc.caFile != "" {
+		// The default verification is replaced so the CA bundle can be rotated without creating a new client,
+		// verifyConnection executes the same chain and host name validation against the current bundle
+		config.InsecureSkipVerify = true // #nosec G402 -- verification is done in verifyConnection
+		config.VerifyConnection = cc.verifyConnection
+	}
+
+	return config
+}
+
+// getClientCertificate returns the current client certificate, reloading it if the files changed
+func (cc *ClientCertificates) getClientCertificate(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cc.reloadIfChanged()
+	cc.mutex.RLock()
+	defer cc.mutex.RUnlock()
+	if cc.certificate == nil {
+		return &tls.Certificate{}, nil
+	}
+
+	return cc.certificate, nil
+}
+
+// verifyConnection verifies the server certificate chain and host name against the current CA bundle
+func (cc *ClientCertificates) verifyConnection(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("server did not present a certificate")
+	}
+
+	cc.reloadIfChanged()
+	cc.mutex.RLock()
+	rootCAs := cc.rootCAs
+	cc.mutex.RUnlock()
+
+	options := x509.VerifyOptions{
+		DNSName:       state.ServerName,
+		Roots:         rootCAs,
+		Intermediates: x509.NewCertPool(),
+	}
+
+	for _, certificate := range state.PeerCertificates[1:] {
+		options.Intermediates.AddCert(certificate)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(options)
+	return err
+}
+
+// reloadIfChanged reloads the certificates if any of the files was modified since the last load
+func (cc *ClientCertificates) reloadIfChanged() {
+	cc.mutex.Lock()
+	if time.Since(cc.lastCheck) < certificateReloadInterval {
+		cc.mutex.Unlock()
+		return
+	}
+
+	cc.lastCheck = time.Now()
+	changed := false
+	for _, file := range cc.getFiles() {
+		info, err := os.Stat(file)
+		if err == nil && !info.ModTime().Equal(cc.modTimes[file]) {
+			changed = true
+		}
+	}
+	cc.mutex.Unlock()
+
+	if changed {
+		cc.Logger.Info("Certificate files changed, reloading", cc.Pack, "reloadIfChanged")
+		err := cc.load()
+		if err != nil {
+			cc.Logger.Error(err, "Error reloading certificates, using previous certificates", cc.Pack, "reloadIfChanged")
+		}
+	}
+}
+
+// load reads the certificate files
+func (cc *ClientCertificates) load() error {
+	var certificate *tls.Certificate
+	var err error
+	if cc.pkcs12File != "" {
+		certificate, err = cc.loadPKCS12()
+	} else if cc.certFile != "" {
+		var pair tls.Certificate
+		pair, err = tls.LoadX509KeyPair(filepath.Clean(cc.certFile), filepath.Clean(cc.keyFile))
+		certificate = &pair
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var rootCAs *x509.CertPool
+	if cc.caFile != "" {
+		data, err := os.ReadFile(filepath.Clean(cc.caFile))
+		if err != nil {
+			return err
+		}
+
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(data) {
+			return errors.New("no certificates found in CA bundle: " + cc.caFile)
+		}
+	}
+
+	modTimes := make(map[string]time.Time)
+	for _, file := range cc.getFiles() {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[file] = info.ModTime()
+		}
+	}
+
+	cc.mutex.Lock()
+	cc.certificate = certificate
+	cc.rootCAs = rootCAs
+	cc.modTimes = modTimes
+	cc.lastCheck = time.Now()
+	cc.mutex.Unlock()
+
+	cc.Logger.Info("Client certificates loaded", cc.Pack, "load")
+	return nil
+}
+
+// loadPKCS12 reads the client certificate and key from a PKCS#12 bundle
+func (cc *ClientCertificates) loadPKCS12() (*tls.Certificate, error) {
+	data, err := os.ReadFile(filepath.Clean(cc.pkcs12File))
+	if err != nil {
+		return nil, err
+	}
+
+	key, certificate, caCerts, err := pkcs12.DecodeChain(data, cc.pkcs12Password)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &tls.Certificate{
+		Certificate: [][]byte{certificate.Raw},
+		PrivateKey:  key,
+		Leaf:        certificate,
+	}
+
+	for _, caCert := range caCerts {
+		result.Certificate = append(result.Certificate, caCert.Raw)
+	}
+
+	return result, nil
+}
+
+// getFiles returns the list of configured certificate files
+func (cc *ClientCertificates) getFiles() []string {
+	result := make([]string, 0)
+	for _, file := range []string{cc.certFile, cc.keyFile, cc.pkcs12File, cc.caFile} {
+		if file != "" {
+			result = append(result, file)
+		}
+	}
+
+	return result
+}
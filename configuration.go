@@ -3,7 +3,11 @@ package configuration
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
 	"github.com/google/uuid"
@@ -15,13 +19,35 @@ const (
 	serverOrgIDEnv = "SERVER_ORG_ID" // constant  to store name of the server id environment variable
 	//loggingLevelEnv    = "LOGGING_LEVEL"    // constant  to store name of the Logging level environment variable
 	//environmentEnv     = "ENVIRONMENT"      // constant  to store name of the environment variable
-	applicationModeEnv = "APPLICATION_MODE" // constant  to store name of the application mode environment variable"
-	transmitterMode    = "TRANSMITTER"      // TRANSMITTER Application mode Constant
-	receiverMode       = "RECEIVER"         // RECEIVER Application mode Constant
+	applicationModeEnv = "APPLICATION_MODE"              // constant  to store name of the application mode environment variable"
+	workerPoolSizeEnv  = "WORKER_POOL_SIZE"              // constant  to store name of the worker pool size environment variable
+	queueCapacityEnv   = "QUEUE_CAPACITY"                // constant  to store name of the queue capacity environment variable
+	queuePolicyEnv     = "QUEUE_FULL_POLICY"             // constant  to store name of the queue full policy environment variable
+	fsyncPolicyEnv     = "PERSISTENT_QUEUE_FSYNC_POLICY" // constant  to store name of the persistent queue fsync policy environment variable
+	segmentSizeEnv     = "PERSISTENT_QUEUE_SEGMENT_SIZE" // constant  to store name of the persistent queue segment size environment variable
+	gracePeriodEnv     = "SHUTDOWN_GRACE_PERIOD"         // constant  to store name of the shutdown grace period environment variable
+	transmitterMode    = "TRANSMITTER"                   // TRANSMITTER Application mode Constant
+	receiverMode       = "RECEIVER"                      // RECEIVER Application mode Constant
 	//proxyURL           = "PROXY_URL"        // RECEIVER Application mode Constant
 	certPath = "/certificates/"
 )
 
+const (
+	// QueueDropNewest discards the incoming message when the queue is full
+	QueueDropNewest = "DROP_NEWEST"
+	// QueueDropOldest discards the oldest queued message to make room for the incoming message
+	QueueDropOldest = "DROP_OLDEST"
+	// QueueReject rejects the incoming request with a 503 status and a Retry-After header
+	QueueReject = "REJECT"
+
+	// FsyncAlways flushes the persistent queue to disk on every write
+	FsyncAlways = "ALWAYS"
+	// FsyncInterval flushes the persistent queue to disk once per second
+	FsyncInterval = "INTERVAL"
+	// FsyncNever leaves the flushing of the persistent queue to the operating system
+	FsyncNever = "NEVER"
+)
+
 var (
 	// ServerID has the OrganisationID for the server
 	ServerID = ""
@@ -98,10 +124,73 @@ func (cnf *Configuration) validateSettings() bool {
 		cnf.Settings.ReportSettings.ExecutionNumber = 0
 	}
 
+	if cnf.Settings.ConfigurationSettings.WorkerPoolSize != 0 && (cnf.Settings.ConfigurationSettings.WorkerPoolSize > 64 || cnf.Settings.ConfigurationSettings.WorkerPoolSize < 0) {
+		cnf.logger.Warning("Value out of range for "+workerPoolSizeEnv+" (1 - 64), using the number of available CPUs", "Configuration", "validateSettings")
+		cnf.Settings.ConfigurationSettings.WorkerPoolSize = 0
+	}
+
+	if cnf.Settings.ConfigurationSettings.WorkerPoolSize == 0 {
+		cnf.Settings.ConfigurationSettings.WorkerPoolSize = runtime.NumCPU()
+		if cnf.Settings.ConfigurationSettings.WorkerPoolSize > 64 {
+			cnf.Settings.ConfigurationSettings.WorkerPoolSize = 64
+		}
+	}
+
+	if cnf.Settings.ConfigurationSettings.QueueCapacity != 0 && (cnf.Settings.ConfigurationSettings.QueueCapacity > 1000000 || cnf.Settings.ConfigurationSettings.QueueCapacity < 100) {
+		cnf.logger.Warning("Value out of range for "+queueCapacityEnv+" (100 - 1000000), using default value from system", "Configuration", "validateSettings")
+		cnf.Settings.ConfigurationSettings.QueueCapacity = 0
+	}
+
+	if cnf.Settings.ConfigurationSettings.QueueCapacity == 0 {
+		cnf.Settings.ConfigurationSettings.QueueCapacity = 1000
+	}
+
+	switch cnf.Settings.ConfigurationSettings.QueueFullPolicy {
+	case QueueDropNewest, QueueDropOldest, QueueReject:
+	case "":
+		cnf.Settings.ConfigurationSettings.QueueFullPolicy = QueueDropNewest
+	default:
+		cnf.logger.Warning("Value not allowed for "+queuePolicyEnv+" ("+QueueDropNewest+", "+QueueDropOldest+", "+QueueReject+"), using "+QueueDropNewest, "Configuration", "validateSettings")
+		cnf.Settings.ConfigurationSettings.QueueFullPolicy = QueueDropNewest
+	}
+
+	if cnf.Settings.ConfigurationSettings.QueueRetryAfter < 1 || cnf.Settings.ConfigurationSettings.QueueRetryAfter > 300 {
+		cnf.Settings.ConfigurationSettings.QueueRetryAfter = 5
+	}
+
+	if cnf.Settings.ConfigurationSettings.ShutdownGracePeriod != 0 && (cnf.Settings.ConfigurationSettings.ShutdownGracePeriod > 600 || cnf.Settings.ConfigurationSettings.ShutdownGracePeriod < 1) {
+		cnf.logger.Warning("Value out of range for "+gracePeriodEnv+" (1 - 600), using default value from system", "Configuration", "validateSettings")
+		cnf.Settings.ConfigurationSettings.ShutdownGracePeriod = 0
+	}
+
+	if cnf.Settings.PersistentQueueSettings.Enabled {
+		cnf.validatePersistentQueueSettings()
+	}
+
 	if cnf.Settings.SecuritySettings.EnableHTTPS {
 		cnf.validateHTTPSCertificates()
 	}
 
+	if !cnf.validateClientCertificates() {
+		isValid = false
+	}
+
+	if !cnf.validateClientAuthentication() {
+		isValid = false
+	}
+
+	if cnf.Settings.SecuritySettings.VerifyAccessToken && !cnf.validateTokenVerification() {
+		isValid = false
+	}
+
+	if !cnf.validateInboundAuthSettings() {
+		isValid = false
+	}
+
+	if cnf.Settings.KafkaSettings.Enabled && !cnf.validateKafkaSettings() {
+		isValid = false
+	}
+
 	if cnf.Settings.ResultSettings.FilesPerDay < 1 || cnf.Settings.ResultSettings.FilesPerDay > 24 {
 		cnf.logger.Warning("Value out of range for RESULT_FILES_PER_DAY (1 - 24), using default value from system", "Configuration", "validateSettings")
 		cnf.Settings.ResultSettings.FilesPerDay = 8
@@ -120,6 +209,34 @@ func (cnf *Configuration) validateSettings() bool {
 	return isValid
 }
 
+// validatePersistentQueueSettings Validates the persistent queue settings, default values are used for the wrong values
+//
+// Parameters:
+// Returns:
+func (cnf *Configuration) validatePersistentQueueSettings() {
+	if cnf.Settings.PersistentQueueSettings.Directory == "" {
+		cnf.Settings.PersistentQueueSettings.Directory = "./queue_data"
+	}
+
+	switch cnf.Settings.PersistentQueueSettings.FsyncPolicy {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+	case "":
+		cnf.Settings.PersistentQueueSettings.FsyncPolicy = FsyncInterval
+	default:
+		cnf.logger.Warning("Value not allowed for "+fsyncPolicyEnv+" ("+FsyncAlways+", "+FsyncInterval+", "+FsyncNever+"), using "+FsyncInterval, "Configuration", "validatePersistentQueueSettings")
+		cnf.Settings.PersistentQueueSettings.FsyncPolicy = FsyncInterval
+	}
+
+	if cnf.Settings.PersistentQueueSettings.SegmentSize != 0 && (cnf.Settings.PersistentQueueSettings.SegmentSize > 1000000 || cnf.Settings.PersistentQueueSettings.SegmentSize < 100) {
+		cnf.logger.Warning("Value out of range for "+segmentSizeEnv+" (100 - 1000000), using default value from system", "Configuration", "validatePersistentQueueSettings")
+		cnf.Settings.PersistentQueueSettings.SegmentSize = 0
+	}
+
+	if cnf.Settings.PersistentQueueSettings.SegmentSize == 0 {
+		cnf.Settings.PersistentQueueSettings.SegmentSize = 10000
+	}
+}
+
 func (cnf *Configuration) validateHTTPSCertificates() bool {
 	certFile := "server.crt"
 	keyFile := "server.key"
@@ -139,6 +256,149 @@ func (cnf *Configuration) validateHTTPSCertificates() bool {
 	return true
 }
 
+// validateClientCertificates Validates the files configured for the mTLS connection with the central server
+//
+// Parameters:
+// Returns: true if validation was ok
+func (cnf *Configuration) validateClientCertificates() bool {
+	security := cnf.Settings.SecuritySettings
+	if security.ClientCertFilePath != "" && security.ClientKeyFilePath == "" {
+		cnf.logger.Warning("ClientKeyFilePath is required when ClientCertFilePath is configured", "Configuration", "validateClientCertificates")
+		return false
+	}
+
+	if security.ServerURL == "" && security.ProxyURL == "" {
+		cnf.logger.Warning("ServerURL or ProxyURL must be configured", "Configuration", "validateClientCertificates")
+		return false
+	}
+
+	for _, file := range []string{security.ClientCertFilePath, security.ClientKeyFilePath, security.ClientPKCS12FilePath, security.CACertFilePath} {
+		if file == "" {
+			continue
+		}
+
+		_, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			cnf.logger.Warning("Certificate file not found: "+file, "Configuration", "validateClientCertificates")
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateClientAuthentication Validates the settings required by the client authentication method used on the token request
+//
+// Parameters:
+// Returns: true if validation was ok
+func (cnf *Configuration) validateClientAuthentication() bool {
+	security := cnf.Settings.SecuritySettings
+	switch security.TokenAuthMethod {
+	case "", "none", "tls_client_auth":
+	case "client_secret_basic", "client_secret_post":
+		if security.ClientSecret == "" {
+			cnf.logger.Warning("ClientSecret is required when TokenAuthMethod is "+security.TokenAuthMethod, "Configuration", "validateClientAuthentication")
+			return false
+		}
+	case "private_key_jwt":
+		if security.ClientAssertionKeyFilePath == "" {
+			cnf.logger.Warning("ClientAssertionKeyFilePath is required when TokenAuthMethod is private_key_jwt", "Configuration", "validateClientAuthentication")
+			return false
+		}
+
+		_, err := os.Stat(security.ClientAssertionKeyFilePath)
+		if os.IsNotExist(err) {
+			cnf.logger.Warning("Client assertion key file not found: "+security.ClientAssertionKeyFilePath, "Configuration", "validateClientAuthentication")
+			return false
+		}
+	default:
+		cnf.logger.Warning("Value not allowed for TokenAuthMethod (none, tls_client_auth, client_secret_basic, client_secret_post, private_key_jwt): "+security.TokenAuthMethod, "Configuration", "validateClientAuthentication")
+		return false
+	}
+
+	return true
+}
+
+// validateTokenVerification Validates the settings required to verify the access tokens issued by the central server
+//
+// Parameters:
+// Returns: true if validation was ok
+func (cnf *Configuration) validateTokenVerification() bool {
+	security := cnf.Settings.SecuritySettings
+	if security.TokenIssuer == "" || security.TokenAudience == "" {
+		cnf.logger.Warning("TokenIssuer and TokenAudience are required when VerifyAccessToken is enabled", "Configuration", "validateTokenVerification")
+		return false
+	}
+
+	jwksURL, err := url.Parse(security.JWKSURL)
+	if err != nil || jwksURL.Scheme != "https" || jwksURL.Host == "" {
+		cnf.logger.Warning("An https JWKSURL is required when VerifyAccessToken is enabled", "Configuration", "validateTokenVerification")
+		return false
+	}
+
+	return true
+}
+
+// validateInboundAuthSettings Validates that every authentication method configured by route has the required settings
+//
+// Parameters:
+// Returns: true if validation was ok
+func (cnf *Configuration) validateInboundAuthSettings() bool {
+	auth := cnf.Settings.InboundAuthSettings
+	for route, methods := range auth.Routes {
+		for _, method := range methods {
+			switch strings.ToUpper(strings.TrimSpace(method)) {
+			case "API_KEY":
+				if len(auth.APIKeys) == 0 {
+					cnf.logger.Warning("APIKeys are required for API_KEY authentication on route "+route, "Configuration", "validateInboundAuthSettings")
+					return false
+				}
+			case "HMAC":
+				if route == "GRPC" {
+					cnf.logger.Warning("HMAC authentication is not supported on route GRPC", "Configuration", "validateInboundAuthSettings")
+					return false
+				}
+
+				if auth.HMACSecret == "" {
+					cnf.logger.Warning("HMACSecret is required for HMAC authentication on route "+route, "Configuration", "validateInboundAuthSettings")
+					return false
+				}
+			case "MTLS":
+				if !cnf.Settings.SecuritySettings.EnableHTTPS || auth.ClientCAFilePath == "" || len(auth.ClientCertificateAllowList) == 0 {
+					cnf.logger.Warning("EnableHTTPS, ClientCAFilePath and ClientCertificateAllowList are required for MTLS authentication on route "+route, "Configuration", "validateInboundAuthSettings")
+					return false
+				}
+			default:
+				cnf.logger.Warning("Authentication method not supported on route "+route+": "+method, "Configuration", "validateInboundAuthSettings")
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// validateKafkaSettings Validates the settings of the Kafka consumer
+//
+// Parameters:
+// Returns: true if validation was ok
+func (cnf *Configuration) validateKafkaSettings() bool {
+	kafka := cnf.Settings.KafkaSettings
+	if len(kafka.Brokers) == 0 || len(kafka.Topics) == 0 || kafka.GroupID == "" {
+		cnf.logger.Warning("Brokers, Topics and GroupID are required when the Kafka consumer is enabled", "Configuration", "validateKafkaSettings")
+		return false
+	}
+
+	switch strings.ToUpper(kafka.SASLMechanism) {
+	case "", "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+	default:
+		cnf.logger.Warning("Value not allowed for Kafka SASLMechanism (PLAIN, SCRAM-SHA-256, SCRAM-SHA-512)", "Configuration", "validateKafkaSettings")
+		return false
+	}
+
+	return true
+}
+
 // loadConfigurationFile Loads the settings from the configuration file
 //
 // Parameters:
@@ -174,5 +434,46 @@ func (cnf *Configuration) loadSettingsFromEnvironment() error {
 		cnf.logger.Error(err, "There was an error processing environment settings.", "configuration", "loadSettingsFromEnvironment")
 	}
 
+	cnf.loadIntFromEnvironment(workerPoolSizeEnv, &cnf.Settings.ConfigurationSettings.WorkerPoolSize)
+	cnf.loadIntFromEnvironment(queueCapacityEnv, &cnf.Settings.ConfigurationSettings.QueueCapacity)
+	cnf.loadStringFromEnvironment(queuePolicyEnv, &cnf.Settings.ConfigurationSettings.QueueFullPolicy)
+	cnf.loadIntFromEnvironment(gracePeriodEnv, &cnf.Settings.ConfigurationSettings.ShutdownGracePeriod)
+	cnf.loadStringFromEnvironment(fsyncPolicyEnv, &cnf.Settings.PersistentQueueSettings.FsyncPolicy)
+	cnf.loadIntFromEnvironment(segmentSizeEnv, &cnf.Settings.PersistentQueueSettings.SegmentSize)
 	return nil
 }
+
+// loadIntFromEnvironment Overwrites a numeric setting with the value of an environment variable, if it is set
+//
+// Parameters:
+//   - name: Name of the environment variable
+//   - value: Setting to be overwritten
+//
+// Returns:
+func (cnf *Configuration) loadIntFromEnvironment(name string, value *int) {
+	envValue, found := os.LookupEnv(name)
+	if !found {
+		return
+	}
+
+	number, err := strconv.Atoi(strings.TrimSpace(envValue))
+	if err != nil {
+		cnf.logger.Warning("Value not allowed for "+name+", using the value from the configuration file", "configuration", "loadIntFromEnvironment")
+		return
+	}
+
+	*value = number
+}
+
+// loadStringFromEnvironment Overwrites a setting with the value of an environment variable in upper case, if it is set
+//
+// Parameters:
+//   - name: Name of the environment variable
+//   - value: Setting to be overwritten
+//
+// Returns:
+func (cnf *Configuration) loadStringFromEnvironment(name string, value *string) {
+	if envValue, found := os.LookupEnv(name); found {
+		*value = strings.ToUpper(strings.TrimSpace(envValue))
+	}
+}
@@ -0,0 +1,423 @@
+package application
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+const (
+	ChangeAPIAdded          = "API_ADDED"          // An API was added to a group
+	ChangeAPIRemoved        = "API_REMOVED"        // An API was removed from a group
+	ChangeVersionChanged    = "VERSION_CHANGED"    // The version of an API changed
+	ChangeEndpointAdded     = "ENDPOINT_ADDED"     // An endpoint was added to an API
+	ChangeEndpointRemoved   = "ENDPOINT_REMOVED"   // An endpoint was removed from an API
+	ChangeSchemaChanged     = "SCHEMA_CHANGED"     // The body or header schema of an endpoint changed
+	ChangeThroughputChanged = "THROUGHPUT_CHANGED" // The throughput of an endpoint changed
+	ChangeRateChanged       = "RATE_CHANGED"       // A validation rate changed
+
+	maxAuditEntries    = 500  // Max number of entries returned by the audit log, and kept in each audit log file
+	auditRotatedSuffix = ".1" // Suffix of the previous audit log file once it is rotated
+)
+
+// ConfigurationChange contains a change between two configuration versions
+type ConfigurationChange struct {
+	Type     string `json:"type"`               // Type of change
+	Group    string `json:"group,omitempty"`    // API group of the change
+	API      string `json:"api,omitempty"`      // API of the change
+	Version  string `json:"version,omitempty"`  // Version of the API of the endpoint changes
+	Endpoint string `json:"endpoint,omitempty"` // Endpoint of the change
+	Method   string `json:"method,omitempty"`   // HTTP method of the endpoint, empty if the endpoint applies to any method
+	Field    string `json:"field,omitempty"`    // Field changed (body_schema, header_schema, or the name of the validation rate)
+	OldValue string `json:"oldValue,omitempty"` // Previous value, schemas are shown as SHA-256 hashes
+	NewValue string `json:"newValue,omitempty"` // New value, schemas are shown as SHA-256 hashes
+}
+
+// ConfigurationDiff contains the changes applied by a configuration update
+type ConfigurationDiff struct {
+	Date       time.Time             `json:"date"`       // Date of the update
+	OldVersion string                `json:"oldVersion"` // Previous version, empty on the first load
+	NewVersion string                `json:"newVersion"` // New version
+	Source     string                `json:"source"`     // Source of the new version (SERVER, CACHE, BUNDLE)
+	Changes    []ConfigurationChange `json:"changes"`    // List of changes
+}
+
+// getConfigurationDiff compares the validation settings of two configurations
+//
+// Parameters:
+//   - oldSettings: Previous configuration, nil on the first load
+//   - newSettings: New configuration
+//
+// Returns:
+//   - []ConfigurationChange: List of changes
+func getConfigurationDiff(oldSettings *models.ConfigurationSettings, newSettings *models.ConfigurationSettings) []ConfigurationChange {
+	changes := make([]ConfigurationChange, 0)
+	oldValidation := models.ValidationSettings{}
+	if oldSettings != nil {
+		oldValidation = oldSettings.ValidationSettings
+	}
+
+	newValidation := newSettings.ValidationSettings
+	rates := []struct {
+		name     string
+		old, new int
+	}{
+		{"TransmitterValidationRate", oldValidation.TransmitterValidationRate, newValidation.TransmitterValidationRate},
+		{"ReceiverValidationRate", oldValidation.ReceiverValidationRate, newValidation.ReceiverValidationRate},
+		{"ExtremelyHighTroughputValidationRate", oldValidation.ExtremelyHighTroughputValidationRate, newValidation.ExtremelyHighTroughputValidationRate},
+		{"HighTroughputValidationRate", oldValidation.HighTroughputValidationRate, newValidation.HighTroughputValidationRate},
+		{"MediumTroughputValidationRate", oldValidation.MediumTroughputValidationRate, newValidation.MediumTroughputValidationRate},
+		{"LowTroughputValidationRate", oldValidation.LowTroughputValidationRate, newValidation.LowTroughputValidationRate},
+		{"VeryLowTroughputValidationRate", oldValidation.VeryLowTroughputValidationRate, newValidation.VeryLowTroughputValidationRate},
+	}
+
+	for _, rate := range rates {
+		if rate.old != rate.new {
+			changes = append(changes, ConfigurationChange{Type: ChangeRateChanged, Field: rate.name, OldValue: strconv.Itoa(rate.old), NewValue: strconv.Itoa(rate.new)})
+		}
+	}
+
+	for _, newGroup := range newValidation.APIGroupSettings {
+		oldGroup := oldValidation.GetGroupSetting(newGroup.Group)
+		for _, newAPI := range newGroup.APIList {
+			var oldAPI *models.APISetting
+			if oldGroup != nil {
+				oldAPI = oldGroup.GetAPIVersionSetting(newAPI.API, newAPI.Version)
+				if oldAPI == nil && isVersionReplaced(oldGroup, &newGroup, newAPI.API) {
+					oldAPI = oldGroup.GetAPISetting(newAPI.API)
+				}
+			}
+
+			if oldAPI == nil {
+				changes = append(changes, ConfigurationChange{Type: ChangeAPIAdded, Group: newGroup.Group, API: newAPI.API, NewValue: newAPI.Version})
+				continue
+			}
+
+			if oldAPI.Version != newAPI.Version {
+				changes = append(changes, ConfigurationChange{Type: ChangeVersionChanged, Group: newGroup.Group, API: newAPI.API, OldValue: oldAPI.Version, NewValue: newAPI.Version})
+			}
+
+			changes = append(changes, getEndpointChanges(newGroup.Group, newAPI.API, newAPI.Version, oldAPI.EndpointList, newAPI.EndpointList)...)
+		}
+
+		if oldGroup == nil {
+			continue
+		}
+
+		for _, oldAPI := range oldGroup.APIList {
+			if newGroup.GetAPIVersionSetting(oldAPI.API, oldAPI.Version) == nil && !isVersionReplaced(oldGroup, &newGroup, oldAPI.API) {
+				changes = append(changes, ConfigurationChange{Type: ChangeAPIRemoved, Group: newGroup.Group, API: oldAPI.API, OldValue: oldAPI.Version})
+			}
+		}
+	}
+
+	for _, oldGroup := range oldValidation.APIGroupSettings {
+		if newValidation.GetGroupSetting(oldGroup.Group) == nil {
+			for _, oldAPI := range oldGroup.APIList {
+				changes = append(changes, ConfigurationChange{Type: ChangeAPIRemoved, Group: oldGroup.Group, API: oldAPI.API, OldValue: oldAPI.Version})
+			}
+		}
+	}
+
+	return changes
+}
+
+// isVersionReplaced indicates if an API has a single version in both configurations and the version changed,
+// APIs with several versions are compared version by version
+//
+// Parameters:
+//   - oldGroup: Previous group settings
+//   - newGroup: New group settings
+//   - api: Name of the API
+//
+// Returns:
+//   - bool: true if the only version of the API was replaced
+func isVersionReplaced(oldGroup *models.APIGroupSetting, newGroup *models.APIGroupSetting, api string) bool {
+	oldVersions, newVersions := oldGroup.GetAPIVersions(api), newGroup.GetAPIVersions(api)
+	return len(oldVersions) == 1 && len(newVersions) == 1 && oldVersions[0] != newVersions[0]
+}
+
+// getEndpointChanges compares the endpoints of an API
+//
+// Parameters:
+//   - group: API group
+//   - api: Name of the API
+//   - version: New version of the API
+//   - oldEndpoints: Previous endpoints
+//   - newEndpoints: New endpoints
+//
+// Returns:
+//   - []ConfigurationChange: List of changes
+func getEndpointChanges(group string, api string, version string, oldEndpoints []models.APIEndpointSetting, newEndpoints []models.APIEndpointSetting) []ConfigurationChange {
+	changes := make([]ConfigurationChange, 0)
+	oldByName := make(map[string]models.APIEndpointSetting)
+	for _, endpoint := range oldEndpoints {
+		oldByName[getEndpointKey(endpoint)] = endpoint
+	}
+
+	for _, newEndpoint := range newEndpoints {
+		oldEndpoint, ok := oldByName[getEndpointKey(newEndpoint)]
+		if !ok {
+			changes = append(changes, ConfigurationChange{Type: ChangeEndpointAdded, Group: group, API: api, Version: version, Endpoint: newEndpoint.Endpoint, Method: newEndpoint.Method})
+			continue
+		}
+
+		delete(oldByName, getEndpointKey(newEndpoint))
+		if oldHash, newHash := getSchemaHash(oldEndpoint.JSONBodySchema), getSchemaHash(newEndpoint.JSONBodySchema); oldHash != newHash {
+			changes = append(changes, ConfigurationChange{Type: ChangeSchemaChanged, Group: group, API: api, Version: version, Endpoint: newEndpoint.Endpoint, Method: newEndpoint.Method, Field: "body_schema", OldValue: oldHash, NewValue: newHash})
+		}
+
+		if oldHash, newHash := getSchemaHash(oldEndpoint.JSONHeaderSchema), getSchemaHash(newEndpoint.JSONHeaderSchema); oldHash != newHash {
+			changes = append(changes, ConfigurationChange{Type: ChangeSchemaChanged, Group: group, API: api, Version: version, Endpoint: newEndpoint.Endpoint, Method: newEndpoint.Method, Field: "header_schema", OldValue: oldHash, NewValue: newHash})
+		}
+
+		if oldEndpoint.Throughput != newEndpoint.Throughput {
+			changes = append(changes, ConfigurationChange{Type: ChangeThroughputChanged, Group: group, API: api, Version: version, Endpoint: newEndpoint.Endpoint, Method: newEndpoint.Method, OldValue: oldEndpoint.Throughput, NewValue: newEndpoint.Throughput})
+		}
+	}
+
+	for _, oldEndpoint := range oldEndpoints {
+		if _, ok := oldByName[getEndpointKey(oldEndpoint)]; ok {
+			changes = append(changes, ConfigurationChange{Type: ChangeEndpointRemoved, Group: group, API: api, Version: version, Endpoint: oldEndpoint.Endpoint, Method: oldEndpoint.Method})
+		}
+	}
+
+	return changes
+}
+
+// getEndpointKey returns the key used to compare the endpoints of an API
+//
+// Parameters:
+//   - endpoint: Endpoint settings
+//
+// Returns:
+//   - string: HTTP method and name of the endpoint
+func getEndpointKey(endpoint models.APIEndpointSetting) string {
+	return strings.ToUpper(strings.TrimSpace(endpoint.Method)) + " " + endpoint.Endpoint
+}
+
+// getSchemaHash returns the SHA-256 hash of a schema
+//
+// Parameters:
+//   - schema: Schema to hash
+//
+// Returns:
+//   - string: Hex encoded hash, empty if there is no schema
+func getSchemaHash(schema string) string {
+	if schema == "" {
+		return ""
+	}
+
+	hash := sha256.Sum256([]byte(schema))
+	return hex.EncodeToString(hash[:])
+}
+
+// ConfigurationAuditLog stores the configuration changes in an append-only file, one JSON entry by line. The file is
+// rotated every maxAuditEntries entries keeping only the previous file, and the last entries are kept in memory
+type ConfigurationAuditLog struct {
+	crosscutting.OFBStruct
+	path    string              // Path of the audit log file
+	mutex   sync.Mutex          // Mutex for the file access
+	loaded  bool                // Indicates if the last entries were loaded from the files
+	entries []ConfigurationDiff // Last entries of the audit log, the oldest first
+	lines   int                 // Number of entries in the active file
+}
+
+// NewConfigurationAuditLog creates a new configuration audit log
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - path: Path of the audit log file
+//
+// Returns:
+//   - *ConfigurationAuditLog: Audit log created
+func NewConfigurationAuditLog(logger log.Logger, path string) *ConfigurationAuditLog {
+	return &ConfigurationAuditLog{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.ConfigurationAuditLog",
+			Logger: logger,
+		},
+		path: path,
+	}
+}
+
+// Append adds an entry to the audit log, the active file is rotated once it reaches maxAuditEntries entries
+//
+// Parameters:
+//   - diff: Changes of the configuration update
+//
+// Returns:
+//   - error: error if the entry could not be written
+func (al *ConfigurationAuditLog) Append(diff ConfigurationDiff) error {
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return err
+	}
+
+	al.mutex.Lock()
+	defer al.mutex.Unlock()
+
+	err = al.load()
+	if err != nil {
+		al.Logger.Error(err, "Error loading configuration audit log", al.Pack, "Append")
+	}
+
+	err = os.MkdirAll(filepath.Dir(al.path), 0750)
+	if err != nil {
+		return err
+	}
+
+	if al.lines >= maxAuditEntries {
+		err = os.Rename(al.path, al.getRotatedPath())
+		if err != nil {
+			return err
+		}
+
+		al.lines = 0
+	}
+
+	file, err := os.OpenFile(filepath.Clean(al.path), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(data, '\n'))
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	al.lines++
+	if al.loaded {
+		al.addEntries(diff)
+	}
+
+	return file.Close()
+}
+
+// GetLastEntries returns the last entries of the audit log, the most recent first
+//
+// Parameters:
+//   - limit: Max number of entries
+//
+// Returns:
+//   - []ConfigurationDiff: Entries found
+//   - error: error if the file could not be read
+func (al *ConfigurationAuditLog) GetLastEntries(limit int) ([]ConfigurationDiff, error) {
+	if limit <= 0 || limit > maxAuditEntries {
+		limit = maxAuditEntries
+	}
+
+	al.mutex.Lock()
+	defer al.mutex.Unlock()
+
+	err := al.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Most recent first
+	entries := make([]ConfigurationDiff, 0, limit)
+	for i := len(al.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		entries = append(entries, al.entries[i])
+	}
+
+	return entries, nil
+}
+
+// load reads the last entries from the rotated and the active files the first time the audit log is used, the mutex
+// must be held by the caller
+//
+// Parameters:
+//
+// Returns:
+//   - error: error if the files could not be read
+func (al *ConfigurationAuditLog) load() error {
+	if al.loaded {
+		return nil
+	}
+
+	rotated, err := al.readFile(al.getRotatedPath())
+	if err != nil {
+		return err
+	}
+
+	active, err := al.readFile(al.path)
+	if err != nil {
+		return err
+	}
+
+	al.loaded = true
+	al.lines = len(active)
+	al.addEntries(append(rotated, active...)...)
+	return nil
+}
+
+// addEntries adds entries to the ones kept in memory, only the last maxAuditEntries are kept
+//
+// Parameters:
+//   - entries: Entries to be added, the oldest first
+//
+// Returns:
+func (al *ConfigurationAuditLog) addEntries(entries ...ConfigurationDiff) {
+	al.entries = append(al.entries, entries...)
+	if len(al.entries) > maxAuditEntries {
+		al.entries = append([]ConfigurationDiff(nil), al.entries[len(al.entries)-maxAuditEntries:]...)
+	}
+}
+
+// readFile reads the entries of an audit log file, invalid entries are skipped
+//
+// Parameters:
+//   - path: Path of the file
+//
+// Returns:
+//   - []ConfigurationDiff: Entries found, empty if the file does not exist
+//   - error: error if the file could not be read
+func (al *ConfigurationAuditLog) readFile(path string) ([]ConfigurationDiff, error) {
+	entries := make([]ConfigurationDiff, 0)
+	file, err := os.Open(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry ConfigurationDiff
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			al.Logger.Warning("Invalid entry in configuration audit log: "+err.Error(), al.Pack, "readFile")
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// getRotatedPath returns the path of the previous audit log file
+//
+// Parameters:
+//
+// Returns:
+//   - string: Path of the rotated file
+func (al *ConfigurationAuditLog) getRotatedPath() string {
+	return al.path + auditRotatedSuffix
+}
@@ -0,0 +1,264 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+const (
+	// ConfigurationSourceServer indicates the configuration was loaded from the central server
+	ConfigurationSourceServer = "SERVER"
+	// ConfigurationSourceCache indicates the configuration was loaded from the last-good local cache
+	ConfigurationSourceCache = "CACHE"
+	// ConfigurationSourceBundle indicates the configuration was loaded from the bundle supplied by the operator
+	ConfigurationSourceBundle = "BUNDLE"
+
+	configurationSettingsFile = "configurationSettings.json" // Name of the file with the configuration settings
+	currentVersionFile        = "current"                    // Name of the file with the last-good cached version
+	cachedVersionsToKeep      = 3                            // Number of cached versions kept on disk
+)
+
+// configurationLoader is the Interface that exposes the methods to read the configuration files,
+// it is implemented by the report server and by the local configuration bundles
+type configurationLoader interface {
+	LoadAPIConfigurationFile(filePath string) ([]byte, error)          // Loads the configuration file specified in the path
+	LoadConfigurationSettings() (*models.ConfigurationSettings, error) // Loads the configuration settings from the configuration file
+}
+
+// configurationBundle reads the configuration from a local directory, with the same layout as the central server:
+// configurationSettings.json and the endpoints.json files on their API paths
+type configurationBundle struct {
+	directory string // Directory of the bundle
+}
+
+// LoadAPIConfigurationFile loads an endpoints file from the bundle
+//
+// Parameters:
+//   - filePath: Path of the file, relative to the bundle
+//
+// Returns:
+//   - []byte: Content of the file
+//   - error: error if the file could not be read
+func (cb *configurationBundle) LoadAPIConfigurationFile(filePath string) ([]byte, error) {
+	path := filepath.Join(cb.directory, filepath.Clean("/"+filePath))
+	return os.ReadFile(path)
+}
+
+// LoadConfigurationSettings loads the configuration settings from the bundle
+//
+// Parameters:
+//
+// Returns:
+//   - *models.ConfigurationSettings: Configuration settings
+//   - error: error if the file could not be read
+func (cb *configurationBundle) LoadConfigurationSettings() (*models.ConfigurationSettings, error) {
+	data, err := os.ReadFile(filepath.Join(cb.directory, configurationSettingsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var settings models.ConfigurationSettings
+	err = json.Unmarshal(data, &settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// ConfigurationCache stores the last configurations loaded from the central server, so the application can start
+// when the server is not reachable
+type ConfigurationCache struct {
+	crosscutting.OFBStruct
+	directory string // Directory of the cache, one sub directory by configuration version
+}
+
+// NewConfigurationCache creates a new configuration cache
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - directory: Directory of the cache
+//
+// Returns:
+//   - *ConfigurationCache: Configuration cache created
+func NewConfigurationCache(logger log.Logger, directory string) *ConfigurationCache {
+	return &ConfigurationCache{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.ConfigurationCache",
+			Logger: logger,
+		},
+		directory: directory,
+	}
+}
+
+// Store writes the configuration settings and the endpoint files of every API to the directory of the version,
+// and marks it as the last-good version. The files are written to a temporary directory first, so an interrupted
+// write never replaces a good version
+//
+// Parameters:
+//   - settings: Configuration settings with the endpoint lists loaded
+//
+// Returns:
+//   - error: error if the cache could not be written
+func (cc *ConfigurationCache) Store(settings *models.ConfigurationSettings) error {
+	if settings.Version == "" || strings.ContainsAny(settings.Version, `/\`) || settings.Version == ".." {
+		return errors.New("configuration version not valid for the cache: " + settings.Version)
+	}
+
+	err := os.MkdirAll(cc.directory, 0750)
+	if err != nil {
+		return err
+	}
+
+	tmpDirectory, err := os.MkdirTemp(cc.directory, ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmpDirectory)
+
+	// The endpoint lists are stored on their own files, as they are on the central server
+	stripped := *settings
+	stripped.ValidationSettings.APIGroupSettings = make([]models.APIGroupSetting, len(settings.ValidationSettings.APIGroupSettings))
+	for i, group := range settings.ValidationSettings.APIGroupSettings {
+		stripped.ValidationSettings.APIGroupSettings[i] = group
+		stripped.ValidationSettings.APIGroupSettings[i].APIList = make([]models.APISetting, len(group.APIList))
+		for j, api := range group.APIList {
+			err = cc.writeJSON(filepath.Join(tmpDirectory, getAPIConfigurationFileName(group.BasePath, api.BasePath, api.Version)), api.EndpointList)
+			if err != nil {
+				return err
+			}
+
+			stripped.ValidationSettings.APIGroupSettings[i].APIList[j] = api
+			stripped.ValidationSettings.APIGroupSettings[i].APIList[j].EndpointList = nil
+		}
+	}
+
+	err = cc.writeJSON(filepath.Join(tmpDirectory, configurationSettingsFile), stripped)
+	if err != nil {
+		return err
+	}
+
+	versionDirectory := filepath.Join(cc.directory, settings.Version)
+	err = os.RemoveAll(versionDirectory)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpDirectory, versionDirectory)
+	if err != nil {
+		return err
+	}
+
+	err = cc.writeCurrentVersion(settings.Version)
+	if err != nil {
+		return err
+	}
+
+	cc.removeOldVersions(settings.Version)
+	return nil
+}
+
+// GetLastGood returns the bundle of the last-good cached version
+//
+// Parameters:
+//
+// Returns:
+//   - configurationLoader: Bundle of the last-good version
+//   - error: error if there is no cached version
+func (cc *ConfigurationCache) GetLastGood() (configurationLoader, error) {
+	version, err := os.ReadFile(filepath.Join(cc.directory, currentVersionFile))
+	if err != nil {
+		return nil, err
+	}
+
+	return &configurationBundle{directory: filepath.Join(cc.directory, filepath.Clean("/"+strings.TrimSpace(string(version))))}, nil
+}
+
+// writeJSON writes a value as JSON, creating the parent directories
+//
+// Parameters:
+//   - path: Path of the file
+//   - value: Value to be written
+//
+// Returns:
+//   - error: error if the file could not be written
+func (cc *ConfigurationCache) writeJSON(path string, value interface{}) error {
+	err := os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// writeCurrentVersion marks the last-good version, replacing the file atomically
+//
+// Parameters:
+//   - version: Version of the configuration
+//
+// Returns:
+//   - error: error if the file could not be written
+func (cc *ConfigurationCache) writeCurrentVersion(version string) error {
+	path := filepath.Join(cc.directory, currentVersionFile)
+	err := os.WriteFile(path+".tmp", []byte(version), 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// removeOldVersions removes the oldest cached versions, keeping the current one
+//
+// Parameters:
+//   - current: Current version
+//
+// Returns:
+func (cc *ConfigurationCache) removeOldVersions(current string) {
+	entries, err := os.ReadDir(cc.directory)
+	if err != nil {
+		cc.Logger.Error(err, "Error reading configuration cache", cc.Pack, "removeOldVersions")
+		return
+	}
+
+	type cachedVersion struct {
+		name    string
+		modTime int64
+	}
+
+	versions := make([]cachedVersion, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == current || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, cachedVersion{name: entry.Name(), modTime: info.ModTime().UnixNano()})
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].modTime > versions[j].modTime })
+	for i := cachedVersionsToKeep - 1; i < len(versions); i++ {
+		err = os.RemoveAll(filepath.Join(cc.directory, versions[i].name))
+		if err != nil {
+			cc.Logger.Error(err, "Error removing cached version: "+versions[i].name, cc.Pack, "removeOldVersions")
+		}
+	}
+}
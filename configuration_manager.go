@@ -2,8 +2,11 @@ package application
 
 import (
 	"encoding/json"
+	"errors"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
@@ -11,6 +14,11 @@ import (
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
 	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
 	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+)
+
+const (
+	fallbackUpdateWindow = 5 * time.Minute // Time between configuration updates while a fallback configuration is in use
 )
 
 var (
@@ -23,25 +31,44 @@ type ConfigurationUpdateStatus struct {
 	LastExecutionDate time.Time            // Indicates the data execution of the configuration update
 	LastUpdatedDate   time.Time            // Indicates the data of the las successful configuration update
 	UpdateMessages    map[time.Time]string // List of error messages if any during the update process
+	Source            string               // Source of the configuration in use (SERVER, CACHE, BUNDLE)
 }
 
 // APIValidationSettings groups the validation settings for a specific API
 type APIValidationSettings struct {
 	EndpointSettings *models.APIEndpointSetting
+	EndpointName     string // Name of the endpoint (endpoint base + endpoint)
 	APIGroup         string
 	API              string
 	APIVersion       string
 	BasePath         string
 }
 
+// GetSchemaKey returns the key used to identify the schemas of the endpoint in the schema registry
+//
+// Parameters:
+//   - schemaType: Type of the schema (body / header)
+//
+// Returns:
+//   - string: Key of the schema
+func (avs *APIValidationSettings) GetSchemaKey(schemaType string) string {
+	if avs.EndpointSettings.Method != "" {
+		return avs.APIGroup + "/" + avs.API + "/" + avs.APIVersion + "/" + avs.EndpointSettings.Endpoint + "/" + strings.ToUpper(avs.EndpointSettings.Method) + "/" + schemaType
+	}
+
+	return avs.APIGroup + "/" + avs.API + "/" + avs.APIVersion + "/" + avs.EndpointSettings.Endpoint + "/" + schemaType
+}
+
 // ConfigurationManager is the manager in charge of handling configuration parameters of the application
 type ConfigurationManager struct {
 	crosscutting.OFBStruct
-	ConfigurationSettings     *models.ConfigurationSettings // Configuration settings for the application
-	processRunning            bool                          // Indicates that the process is running
-	mqdServer                 services.ReportServer         // Report server for MQD
-	configurationUpdateStatus ConfigurationUpdateStatus     // Last status of the configuration update
+	snapshot                  atomic.Pointer[ConfigurationSnapshot] // Snapshot of the configuration in use
+	processRunning            bool                                  // Indicates that the process is running
+	mqdServer                 services.ReportServer                 // Report server for MQD
+	configurationUpdateStatus ConfigurationUpdateStatus             // Last status of the configuration update
 	settings                  configuration.Settings
+	cache                     *ConfigurationCache    // Local cache of the last-good configurations
+	auditLog                  *ConfigurationAuditLog // Audit log of the configuration changes
 }
 
 // NewConfigurationManager creates a new configuration manager for the application
@@ -65,13 +92,16 @@ func NewConfigurationManager(logger log.Logger, mqdServer services.ReportServer,
 			settings:  settings,
 		}
 
+		configurationManagerSingleton.cache = NewConfigurationCache(logger, configurationManagerSingleton.GetConfigurationCacheDirectory())
+		configurationManagerSingleton.auditLog = NewConfigurationAuditLog(logger, configurationManagerSingleton.GetConfigurationAuditLogPath())
+
 		configurationManagerSingleton.configurationUpdateStatus.UpdateMessages = make(map[time.Time]string)
 	}
 
 	return configurationManagerSingleton
 }
 
-// getAPIConfigurationFile returns configuration settings for the specified API
+// getAPIConfigurationFileName returns the path of the endpoints file for the specified API
 //
 // Parameters:
 //   - basePath: Base path of the api group
@@ -79,15 +109,29 @@ func NewConfigurationManager(logger log.Logger, mqdServer services.ReportServer,
 //   - apiVersion: api version of the endpoint
 //
 // Returns:
-//   - []models.APIEndpointSetting: Array with endpoint settings for each of the endpoints in the api
-//   - error: error if any
-func (cm *ConfigurationManager) getAPIConfigurationFile(basePath string, apiPath string, apiVersion string) ([]models.APIEndpointSetting, error) {
+//   - string: Path of the endpoints file
+func getAPIConfigurationFileName(basePath string, apiPath string, apiVersion string) string {
 	apiConfigurationPath := basePath + "//" + apiPath + "//" + apiVersion + "//response//"
 	apiConfigurationPath = strings.ReplaceAll(apiConfigurationPath, "ParameterData//", "")
 	apiConfigurationPath = strings.ReplaceAll(apiConfigurationPath, "//", "/")
-	fileName := apiConfigurationPath + "endpoints.json"
+	return apiConfigurationPath + "endpoints.json"
+}
+
+// getAPIConfigurationFile returns configuration settings for the specified API
+//
+// Parameters:
+//   - loader: Loader of the configuration files
+//   - basePath: Base path of the api group
+//   - apiPath: Path for the specific API
+//   - apiVersion: api version of the endpoint
+//
+// Returns:
+//   - []models.APIEndpointSetting: Array with endpoint settings for each of the endpoints in the api
+//   - error: error if any
+func (cm *ConfigurationManager) getAPIConfigurationFile(loader configurationLoader, basePath string, apiPath string, apiVersion string) ([]models.APIEndpointSetting, error) {
+	fileName := getAPIConfigurationFileName(basePath, apiPath, apiVersion)
 	cm.Logger.Debug("loading File Name: "+fileName, cm.Pack, "getAPIConfigurationFile")
-	file, err := cm.mqdServer.LoadAPIConfigurationFile(fileName)
+	file, err := loader.LoadAPIConfigurationFile(fileName)
 	if err != nil {
 		cm.Logger.Error(err, "Error Reading Header schema file: "+fileName, cm.Pack, "getAPIConfigurationFile")
 		return nil, err
@@ -106,19 +150,21 @@ func (cm *ConfigurationManager) getAPIConfigurationFile(basePath string, apiPath
 // updateValidationSchemas checks and updates the validation schemas for the endpoints
 //
 // Parameters:
+//   - loader: Loader of the configuration files
 //   - newSettings: new configuration settings to update
 //
 // Returns:
 //   - error: error if any
-func (cm *ConfigurationManager) updateValidationSettings(newSettings *models.ConfigurationSettings) error {
+func (cm *ConfigurationManager) updateValidationSettings(loader configurationLoader, newSettings *models.ConfigurationSettings) error {
 	cm.Logger.Info("Updating Validation Schemas.", cm.Pack, "updateValidationSchemas")
 
-	if cm.ConfigurationSettings == nil {
+	current := cm.GetSnapshot()
+	if current == nil {
 		cm.Logger.Info("Executing first load", cm.Pack, "updateValidationSettings")
 		for i, newSet := range newSettings.ValidationSettings.APIGroupSettings {
 			for j, newAPI := range newSet.APIList {
 				cm.Logger.Info("Loading API: "+newAPI.API, cm.Pack, "updateValidationSettings")
-				epList, err := cm.getAPIConfigurationFile(newSet.BasePath, newAPI.BasePath, newAPI.Version)
+				epList, err := cm.getAPIConfigurationFile(loader, newSet.BasePath, newAPI.BasePath, newAPI.Version)
 				if err != nil {
 					return err
 				}
@@ -131,10 +177,10 @@ func (cm *ConfigurationManager) updateValidationSettings(newSettings *models.Con
 	}
 
 	for i, newSet := range newSettings.ValidationSettings.APIGroupSettings {
-		oldSet := cm.ConfigurationSettings.ValidationSettings.GetGroupSetting(newSet.Group)
+		oldSet := current.Settings.ValidationSettings.GetGroupSetting(newSet.Group)
 		if oldSet == nil {
 			for j, newAPI := range newSet.APIList {
-				epList, err := cm.getAPIConfigurationFile(newSet.BasePath, newAPI.BasePath, newAPI.Version)
+				epList, err := cm.getAPIConfigurationFile(loader, newSet.BasePath, newAPI.BasePath, newAPI.Version)
 				if err != nil {
 					cm.Logger.Error(err, "error loading api configuration file", cm.Pack, "updateValidationSettings")
 					return err
@@ -145,10 +191,10 @@ func (cm *ConfigurationManager) updateValidationSettings(newSettings *models.Con
 		} else {
 			for j, newAPI := range newSet.APIList {
 				cm.Logger.Debug("Cehecking API: "+newAPI.API, cm.Pack, "updateValidationSettings")
-				oldAPI := oldSet.GetAPISetting(newAPI.API)
-				if oldAPI == nil || oldAPI.Version != newAPI.Version {
+				oldAPI := oldSet.GetAPIVersionSetting(newAPI.API, newAPI.Version)
+				if oldAPI == nil {
 					cm.Logger.Info("Updating API: "+newAPI.API, cm.Pack, "updateValidationSettings")
-					epList, err := cm.getAPIConfigurationFile(newSet.BasePath, newAPI.BasePath, newAPI.Version)
+					epList, err := cm.getAPIConfigurationFile(loader, newSet.BasePath, newAPI.BasePath, newAPI.Version)
 					if err != nil {
 						cm.Logger.Error(err, "error loading api configuration file", cm.Pack, "updateValidationSettings")
 						return err
@@ -175,48 +221,152 @@ func (cm *ConfigurationManager) updateConfiguration() error {
 	cm.Logger.Info("Executing configuration update", cm.Pack, "updateConfiguration")
 
 	cm.configurationUpdateStatus.LastExecutionDate = time.Now()
-	cs, err := cm.mqdServer.LoadConfigurationSettings()
+	updated, err := cm.loadConfiguration(cm.mqdServer, ConfigurationSourceServer)
 	if err != nil {
-		cm.configurationUpdateStatus.UpdateMessages[time.Now()] = err.Error()
+		cm.configurationUpdateStatus.UpdateMessages[cm.configurationUpdateStatus.LastExecutionDate] = err.Error()
 		return err
 	}
 
-	if cm.ConfigurationSettings != nil && cs.Version == cm.ConfigurationSettings.Version {
-		cm.Logger.Info("Same configuration version was found.", cm.Pack, "updateConfiguration")
-		return nil
+	if updated {
+		err = cm.cache.Store(cm.GetSnapshot().Settings)
+		if err != nil {
+			cm.Logger.Error(err, "Error storing configuration cache", cm.Pack, "updateConfiguration")
+		}
 	}
 
-	err = cm.updateValidationSettings(cs)
+	return nil
+}
+
+// loadConfiguration loads the configuration settings and the endpoint files from a loader, the settings are
+// replaced only if the version is different from the current one
+//
+// Parameters:
+//   - loader: Loader of the configuration files
+//   - source: Source of the loader (SERVER, CACHE, BUNDLE)
+//
+// Returns:
+//   - bool: true if a new version was loaded
+//   - error: error if any
+func (cm *ConfigurationManager) loadConfiguration(loader configurationLoader, source string) (bool, error) {
+	cs, err := loader.LoadConfigurationSettings()
 	if err != nil {
-		cm.configurationUpdateStatus.UpdateMessages[cm.configurationUpdateStatus.LastExecutionDate] = err.Error()
-		return err
+		return false, err
+	}
+
+	current := cm.GetSnapshot()
+	if current != nil && cs.Version == current.GetVersion() {
+		cm.Logger.Info("Same configuration version was found.", cm.Pack, "loadConfiguration")
+		if source == ConfigurationSourceServer && cm.configurationUpdateStatus.Source != ConfigurationSourceServer {
+			// The configuration loaded from a fallback is the one published by the server
+			configurationManagerMutex.Lock()
+			cm.configurationUpdateStatus.Source = source
+			cm.configurationUpdateStatus.LastUpdatedDate = cm.configurationUpdateStatus.LastExecutionDate
+			cm.configurationUpdateStatus.UpdateMessages = make(map[time.Time]string)
+			configurationManagerMutex.Unlock()
+		}
+
+		return false, nil
+	}
+
+	err = cm.updateValidationSettings(loader, cs)
+	if err != nil {
+		return false, err
+	}
+
+	// The new settings are completed before publishing them, the published snapshot is never modified
+	cs.SecuritySettings.AttributesToMask = append(cs.SecuritySettings.AttributesToMask, "companyCnpj")
+	var oldSettings *models.ConfigurationSettings
+	if current != nil {
+		oldSettings = current.Settings
 	}
 
+	snapshot := newConfigurationSnapshot(cm.Logger, cs)
 	configurationManagerMutex.Lock()
-	cm.ConfigurationSettings = cs
-	cm.ConfigurationSettings.SecuritySettings.AttributesToMask = append(cm.ConfigurationSettings.SecuritySettings.AttributesToMask, "companyCnpj")
-	cm.configurationUpdateStatus.LastUpdatedDate = cm.configurationUpdateStatus.LastExecutionDate
-	cm.configurationUpdateStatus.UpdateMessages = make(map[time.Time]string)
-	cm.Logger.Info("Configuration was updated to the latest version: "+cm.ConfigurationSettings.Version, cm.Pack, "updateConfiguration")
+	cm.snapshot.Store(snapshot)
+	cm.configurationUpdateStatus.Source = source
+	if source == ConfigurationSourceServer {
+		cm.configurationUpdateStatus.LastUpdatedDate = cm.configurationUpdateStatus.LastExecutionDate
+		cm.configurationUpdateStatus.UpdateMessages = make(map[time.Time]string)
+	}
+
+	validation.GetSchemaRegistry(cm.Logger).SetVersion(cs.Version)
+	cm.Logger.Info("Configuration was updated to version: "+cs.Version+", source: "+source, cm.Pack, "loadConfiguration")
 	configurationManagerMutex.Unlock()
 
-	return nil
+	cm.auditConfigurationChange(oldSettings, cs, source)
+	return true, nil
 }
 
-// getAPIGroupSettings return the settings of API groups
+// auditConfigurationChange writes the changes between two configuration versions to the audit log
 //
 // Parameters:
+//   - oldSettings: Previous configuration, nil on the first load
+//   - newSettings: New configuration
+//   - source: Source of the new configuration (SERVER, CACHE, BUNDLE)
 //
 // Returns:
-//   - []models.APIGroupSetting: Array of APIGroupSetting found
-func (cm *ConfigurationManager) getAPIGroupSettings() []models.APIGroupSetting {
-	configurationManagerMutex.Lock()
-	defer func() {
-		configurationManagerMutex.Unlock()
-	}()
+func (cm *ConfigurationManager) auditConfigurationChange(oldSettings *models.ConfigurationSettings, newSettings *models.ConfigurationSettings, source string) {
+	diff := ConfigurationDiff{
+		Date:       time.Now(),
+		NewVersion: newSettings.Version,
+		Source:     source,
+		Changes:    getConfigurationDiff(oldSettings, newSettings),
+	}
+
+	if oldSettings != nil {
+		diff.OldVersion = oldSettings.Version
+	}
 
-	result := cm.ConfigurationSettings.ValidationSettings.APIGroupSettings
-	return result
+	cm.Logger.Info("Configuration changes from version ["+diff.OldVersion+"] to ["+diff.NewVersion+"]: "+strconv.Itoa(len(diff.Changes)), cm.Pack, "auditConfigurationChange")
+	err := cm.auditLog.Append(diff)
+	if err != nil {
+		cm.Logger.Error(err, "Error writing configuration audit log", cm.Pack, "auditConfigurationChange")
+	}
+}
+
+// GetConfigurationChanges returns the last configuration changes registered in the audit log
+//
+// Parameters:
+//   - limit: Max number of entries to return
+//
+// Returns:
+//   - []ConfigurationDiff: Configuration changes, the most recent first
+//   - error: error if the audit log could not be read
+func (cm *ConfigurationManager) GetConfigurationChanges(limit int) ([]ConfigurationDiff, error) {
+	return cm.auditLog.GetLastEntries(limit)
+}
+
+// loadFallbackConfiguration loads the last-good cached configuration, or the bundle supplied by the operator
+// if there is no cache, when the central server is not reachable
+//
+// Parameters:
+//
+// Returns:
+//   - error: error if no fallback configuration could be loaded
+func (cm *ConfigurationManager) loadFallbackConfiguration() error {
+	lastGood, err := cm.cache.GetLastGood()
+	if err == nil {
+		_, err = cm.loadConfiguration(lastGood, ConfigurationSourceCache)
+		if err == nil {
+			cm.Logger.Warning("Central server not reachable, using cached configuration: "+cm.GetSnapshot().GetVersion(), cm.Pack, "loadFallbackConfiguration")
+			return nil
+		}
+
+		cm.Logger.Error(err, "Error loading cached configuration", cm.Pack, "loadFallbackConfiguration")
+	}
+
+	bundlePath := cm.settings.ConfigurationSettings.ConfigurationBundlePath
+	if bundlePath == "" {
+		return errors.New("no cached configuration or configuration bundle available")
+	}
+
+	_, err = cm.loadConfiguration(&configurationBundle{directory: bundlePath}, ConfigurationSourceBundle)
+	if err != nil {
+		return err
+	}
+
+	cm.Logger.Warning("Central server not reachable, using configuration bundle: "+cm.GetSnapshot().GetVersion(), cm.Pack, "loadFallbackConfiguration")
+	return nil
 }
 
 // StartUpdateProcess starts the periodic process that prints total results and clears them every 2 minutes
@@ -236,13 +386,31 @@ func (cm *ConfigurationManager) StartUpdateProcess() {
 		timeWindow = time.Duration(4) * time.Hour
 	}
 
-	ticker := time.NewTicker(timeWindow)
+	ticker := time.NewTicker(cm.getUpdateWindow(timeWindow))
 	for range ticker.C {
 		err := cm.updateConfiguration()
 		if err != nil {
 			cm.Logger.Error(err, "Error updating configuration", cm.Pack, "StartUpdateProcess")
 		}
+
+		ticker.Reset(cm.getUpdateWindow(timeWindow))
+	}
+}
+
+// getUpdateWindow returns the time to wait for the next configuration update, the server is checked more often
+// while a fallback configuration is in use
+//
+// Parameters:
+//   - timeWindow: Time window configured
+//
+// Returns:
+//   - time.Duration: Time to wait for the next update
+func (cm *ConfigurationManager) getUpdateWindow(timeWindow time.Duration) time.Duration {
+	if cm.GetConfigurationSource() != ConfigurationSourceServer && timeWindow > fallbackUpdateWindow {
+		return fallbackUpdateWindow
 	}
+
+	return timeWindow
 }
 
 // Initialize executes initial settings configuration
@@ -252,43 +420,96 @@ func (cm *ConfigurationManager) StartUpdateProcess() {
 // Returns:
 //   - error: error if any
 func (cm *ConfigurationManager) Initialize() error {
-	return cm.updateConfiguration()
+	err := cm.updateConfiguration()
+	if err == nil {
+		return nil
+	}
+
+	cm.Logger.Error(err, "Error loading configuration from the central server, trying fallback configuration", cm.Pack, "Initialize")
+	fallbackErr := cm.loadFallbackConfiguration()
+	if fallbackErr != nil {
+		cm.Logger.Error(fallbackErr, "Error loading fallback configuration", cm.Pack, "Initialize")
+		return err
+	}
+
+	return nil
+}
+
+// GetSnapshot returns the snapshot of the configuration in use, messages must capture the snapshot once so they are
+// processed with a single configuration version
+//
+// Parameters:
+// Returns:
+//   - *ConfigurationSnapshot: Current snapshot, nil if no configuration was loaded
+func (cm *ConfigurationManager) GetSnapshot() *ConfigurationSnapshot {
+	return cm.snapshot.Load()
 }
 
-// GetEndpointSettingFromAPI loads a specific endpoint setting based on the endpoint name
+// captureSnapshot returns the configuration snapshot of a message, the current snapshot is assigned to the message
+// if it has none (new messages, or messages restored from the persistent queue)
 //
 // Parameters:
-//   - endpointName: Name of the endpoint to lookup for settings
-//   - logger: logger object to be used
+//   - msg: Message to get the snapshot from
 //
 // Returns:
-//   - *models.APIEndpointSetting: error if any
-//   - string: version of the api
-func (cm *ConfigurationManager) GetEndpointSettingFromAPI(endpointName string, logger log.Logger) *APIValidationSettings {
-	cm.Logger.Info("loading Settings from API", cm.Pack, "GetEndpointSettingFromAPI")
-	settings := cm.getAPIGroupSettings()
+//   - *ConfigurationSnapshot: Snapshot of the message
+func (cm *ConfigurationManager) captureSnapshot(msg *Message) *ConfigurationSnapshot {
+	if msg.snapshot == nil {
+		msg.snapshot = cm.GetSnapshot()
+		msg.ConfigurationVersion = msg.snapshot.GetVersion()
+	}
 
-	for _, setting := range settings {
-		for _, api := range setting.APIList {
-			if strings.Contains(strings.ToLower(endpointName), strings.ToLower(strings.TrimSpace(api.EndpointBase))) {
-				for _, endpoint := range api.EndpointList {
-					apiEndpointName := strings.ToLower(strings.TrimSpace(strings.TrimSpace(api.EndpointBase) + strings.TrimSpace(endpoint.Endpoint)))
-					if apiEndpointName == strings.ToLower(strings.TrimSpace(endpointName)) {
-						return &APIValidationSettings{
-							EndpointSettings: &endpoint,
-							APIVersion:       api.Version,
-							API:              api.API,
-							APIGroup:         setting.Group,
-							BasePath:         api.BasePath,
-						}
-					}
-				}
-			}
-		}
+	return msg.snapshot
+}
+
+// getValidationSettings returns the validation settings of the message endpoint, resolved once with the
+// configuration snapshot of the message. Messages without endpoint name are resolved by request path and method,
+// once resolved the configured endpoint name is assigned to the message
+//
+// Parameters:
+//   - msg: Message to get the validation settings from
+//
+// Returns:
+//   - *APIValidationSettings: Validation settings of the endpoint, nil if it is not supported
+func (cm *ConfigurationManager) getValidationSettings(msg *Message) *APIValidationSettings {
+	if msg.validationSettings != nil {
+		return msg.validationSettings
 	}
 
-	logger.Debug("Endpoint Name not found.", "validation-settings", "GetEndpointSettingFromAPI")
-	return nil
+	snapshot := cm.captureSnapshot(msg)
+	if msg.Endpoint != "" {
+		msg.validationSettings = snapshot.GetEndpointSettingFromAPI(msg.Endpoint, msg.RequestMethod, msg.APIVersion, cm.Logger)
+	} else if msg.RequestPath != "" {
+		msg.validationSettings = snapshot.GetEndpointSettingFromAPI(msg.RequestPath, msg.RequestMethod, msg.APIVersion, cm.Logger)
+	}
+
+	// The results are reported with the configured name, the name received may differ in case, spaces or parameter values
+	if msg.validationSettings != nil {
+		msg.Endpoint = msg.validationSettings.EndpointName
+	}
+
+	return msg.validationSettings
+}
+
+// getEndpointAnyVersion returns the validation settings of the message endpoint for the highest version configured,
+// it is used to tell apart the messages with a version not supported from the ones with an endpoint not supported
+//
+// Parameters:
+//   - msg: Message to get the validation settings from
+//
+// Returns:
+//   - *APIValidationSettings: Validation settings of the endpoint, nil if the endpoint is not supported by any version
+func (cm *ConfigurationManager) getEndpointAnyVersion(msg *Message) *APIValidationSettings {
+	if msg.APIVersion == "" {
+		return nil
+	}
+
+	endpointName := msg.Endpoint
+	if endpointName == "" {
+		endpointName = msg.RequestPath
+	}
+
+	return cm.captureSnapshot(msg).GetEndpointSettingFromAPI(endpointName, msg.RequestMethod, "", cm.Logger)
 }
 
 // GetLastExecutionDate returns the las execution date
@@ -332,7 +553,7 @@ func (cm *ConfigurationManager) GetReportExecutionWindow() int {
 		return cm.settings.ReportSettings.ExecutionWindow
 	}
 
-	return cm.ConfigurationSettings.ReportSettings.ReportExecutionWindow
+	return cm.GetSnapshot().Settings.ReportSettings.ReportExecutionWindow
 }
 
 // GetSendOnReportNumber returns the number of reports that should be sent
@@ -346,7 +567,175 @@ func (cm *ConfigurationManager) GetSendOnReportNumber() int {
 		return cm.settings.ReportSettings.ExecutionNumber
 	}
 
-	return cm.ConfigurationSettings.ReportSettings.SendOnReportNumber
+	return cm.GetSnapshot().Settings.ReportSettings.SendOnReportNumber
+}
+
+// GetWorkerPoolSize returns the number of workers that will process the message queue
+//
+// Parameters:
+//
+// Returns:
+//   - int: number of workers
+func (cm *ConfigurationManager) GetWorkerPoolSize() int {
+	if cm.settings.ConfigurationSettings.WorkerPoolSize > 0 {
+		return cm.settings.ConfigurationSettings.WorkerPoolSize
+	}
+
+	return 1
+}
+
+// GetQueueCapacity returns the capacity of the message queue
+//
+// Parameters:
+//
+// Returns:
+//   - int: number of messages that can be queued
+func (cm *ConfigurationManager) GetQueueCapacity() int {
+	if cm.settings.ConfigurationSettings.QueueCapacity > 0 {
+		return cm.settings.ConfigurationSettings.QueueCapacity
+	}
+
+	return 1000
+}
+
+// GetQueueFullPolicy returns the policy to apply when the message queue is full
+//
+// Parameters:
+//
+// Returns:
+//   - string: queue full policy
+func (cm *ConfigurationManager) GetQueueFullPolicy() string {
+	if cm.settings.ConfigurationSettings.QueueFullPolicy != "" {
+		return cm.settings.ConfigurationSettings.QueueFullPolicy
+	}
+
+	return configuration.QueueDropNewest
+}
+
+// IsSyncValidationEnabled indicates if the messages can be validated synchronously with mode=sync
+//
+// Parameters:
+//
+// Returns:
+//   - bool: true if the synchronous validation is enabled
+func (cm *ConfigurationManager) IsSyncValidationEnabled() bool {
+	return cm.settings.ConfigurationSettings.EnableSyncValidation
+}
+
+// GetQueueRetryAfter returns the number of seconds the client should wait when a request is rejected
+//
+// Parameters:
+//
+// Returns:
+//   - int: seconds to wait
+func (cm *ConfigurationManager) GetQueueRetryAfter() int {
+	if cm.settings.ConfigurationSettings.QueueRetryAfter > 0 {
+		return cm.settings.ConfigurationSettings.QueueRetryAfter
+	}
+
+	return 5
+}
+
+// GetShutdownGracePeriod returns the time the application has to finish pending work after a stop signal
+//
+// Parameters:
+//
+// Returns:
+//   - time.Duration: grace period for the shutdown
+func (cm *ConfigurationManager) GetShutdownGracePeriod() time.Duration {
+	if cm.settings.ConfigurationSettings.ShutdownGracePeriod > 0 {
+		return time.Duration(cm.settings.ConfigurationSettings.ShutdownGracePeriod) * time.Second
+	}
+
+	return 25 * time.Second
+}
+
+// GetOutboxDirectory returns the directory where the reports that could not be sent are stored
+//
+// Parameters:
+//
+// Returns:
+//   - string: outbox directory
+func (cm *ConfigurationManager) GetOutboxDirectory() string {
+	if cm.settings.ReportSettings.OutboxDirectory != "" {
+		return cm.settings.ReportSettings.OutboxDirectory
+	}
+
+	return "./report_outbox"
+}
+
+// GetOutboxMaxReports returns the max number of reports stored in the outbox
+//
+// Parameters:
+//
+// Returns:
+//   - int: max number of reports
+func (cm *ConfigurationManager) GetOutboxMaxReports() int {
+	if cm.settings.ReportSettings.OutboxMaxReports > 0 {
+		return cm.settings.ReportSettings.OutboxMaxReports
+	}
+
+	return 500
+}
+
+// GetOutboxMaxAge returns the max time a report is kept in the outbox
+//
+// Parameters:
+//
+// Returns:
+//   - time.Duration: max age of the reports
+func (cm *ConfigurationManager) GetOutboxMaxAge() time.Duration {
+	if cm.settings.ReportSettings.OutboxMaxAge > 0 {
+		return time.Duration(cm.settings.ReportSettings.OutboxMaxAge) * time.Hour
+	}
+
+	return 72 * time.Hour
+}
+
+// GetGRPCPort returns the port of the gRPC ingestion API
+//
+// Parameters:
+// Returns:
+//   - string: gRPC port, empty if the gRPC API is disabled
+func (cm *ConfigurationManager) GetGRPCPort() string {
+	return cm.settings.ConfigurationSettings.GRPCPort
+}
+
+// GetConfigurationSource returns the source of the configuration in use
+//
+// Parameters:
+// Returns:
+//   - string: SERVER, CACHE or BUNDLE
+func (cm *ConfigurationManager) GetConfigurationSource() string {
+	configurationManagerMutex.Lock()
+	defer configurationManagerMutex.Unlock()
+	return cm.configurationUpdateStatus.Source
+}
+
+// GetConfigurationCacheDirectory returns the directory of the local configuration cache
+//
+// Parameters:
+// Returns:
+//   - string: Directory of the cache, ./configuration_cache by default
+func (cm *ConfigurationManager) GetConfigurationCacheDirectory() string {
+	if cm.settings.ConfigurationSettings.ConfigurationCacheDirectory == "" {
+		return "./configuration_cache"
+	}
+
+	return cm.settings.ConfigurationSettings.ConfigurationCacheDirectory
+}
+
+// GetConfigurationAuditLogPath returns the path of the configuration audit log
+//
+// Parameters:
+// Returns:
+//   - string: Path of the audit log, ./configuration_audit.log by default
+func (cm *ConfigurationManager) GetConfigurationAuditLogPath() string {
+	if cm.settings.ConfigurationSettings.ConfigurationAuditLogPath == "" {
+		return "./configuration_audit.log"
+	}
+
+	return cm.settings.ConfigurationSettings.ConfigurationAuditLogPath
 }
 
 // IsHTTPS indicates if the application should be configured as HTTP or HTTPS
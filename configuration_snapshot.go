@@ -0,0 +1,83 @@
+package application
+
+import (
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+// ConfigurationSnapshot is an immutable version of the configuration settings, a new snapshot is published on every
+// configuration update and the published snapshots must not be modified
+type ConfigurationSnapshot struct {
+	Settings *models.ConfigurationSettings // Configuration settings of the snapshot
+	index    *EndpointIndex                // Endpoint index of the validation settings
+}
+
+// newConfigurationSnapshot creates the snapshot of a configuration and builds its endpoint index, the settings
+// must not be modified once the snapshot is created
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - settings: Configuration settings of the snapshot
+//
+// Returns:
+//   - *ConfigurationSnapshot: Snapshot created
+func newConfigurationSnapshot(logger log.Logger, settings *models.ConfigurationSettings) *ConfigurationSnapshot {
+	return &ConfigurationSnapshot{
+		Settings: settings,
+		index:    newEndpointIndex(logger, &settings.ValidationSettings),
+	}
+}
+
+// GetVersion returns the configuration version of the snapshot
+//
+// Parameters:
+// Returns:
+//   - string: Configuration version
+func (snp *ConfigurationSnapshot) GetVersion() string {
+	return snp.Settings.Version
+}
+
+// GetValidationRate returns the validation rate configured for a throughput
+//
+// Parameters:
+//   - throughput: Throughput of the endpoint
+//
+// Returns:
+//   - int: Validation rate in %, 100 if the throughput is unknown
+func (snp *ConfigurationSnapshot) GetValidationRate(throughput string) int {
+	validationSettings := &snp.Settings.ValidationSettings
+	switch throughput {
+	case models.ExtremelyHighTroughput:
+		return validationSettings.ExtremelyHighTroughputValidationRate
+	case models.HighTroughput:
+		return validationSettings.HighTroughputValidationRate
+	case models.MediumTroughput:
+		return validationSettings.MediumTroughputValidationRate
+	case models.LowTroughput:
+		return validationSettings.LowTroughputValidationRate
+	case models.VeryLowTroughput:
+		return validationSettings.VeryLowTroughputValidationRate
+	}
+
+	return 100
+}
+
+// GetEndpointSettingFromAPI returns the validation settings of an endpoint based on the endpoint name,
+// or the request path
+//
+// Parameters:
+//   - endpointName: Name of the endpoint, or request path, to lookup for settings
+//   - method: HTTP method of the request, empty if it is not known
+//   - version: Version or version range of the API (2.1.0, 2.x), empty for the highest version configured
+//   - logger: logger object to be used
+//
+// Returns:
+//   - *APIValidationSettings: Validation settings of the endpoint, nil if it is not supported
+func (snp *ConfigurationSnapshot) GetEndpointSettingFromAPI(endpointName string, method string, version string, logger log.Logger) *APIValidationSettings {
+	validationSettings := snp.index.Lookup(endpointName, method, version)
+	if validationSettings == nil {
+		logger.Debug("Endpoint Name not found.", "validation-settings", "GetEndpointSettingFromAPI")
+	}
+
+	return validationSettings
+}
@@ -0,0 +1,299 @@
+package application
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+// endpointVersion contains the validation settings of a version of an endpoint
+type endpointVersion struct {
+	version  apiVersion             // Parsed version of the API
+	valid    bool                   // Indicates if the version of the API could be parsed
+	settings *APIValidationSettings // Validation settings of the version
+}
+
+// endpointRoute is a path registered in the endpoint index, with the validation settings of its methods and versions
+type endpointRoute struct {
+	segments    []string                     // Path segments in lower case
+	baseLength  int                          // Length of the endpoint base of the API
+	order       int                          // Position of the path in the configuration
+	methods     map[string][]endpointVersion // Versions by HTTP method (empty method for any method), the highest version first
+	firstMethod string                       // First HTTP method configured for the path
+}
+
+// getSettings returns the validation settings of the route for an HTTP method and version, the settings of the
+// method are used first and then the ones for any method. If the method is not known the first method configured
+// is used
+//
+// Parameters:
+//   - method: HTTP method of the request, empty if it is not known
+//   - version: Version or version range requested, empty for the highest version
+//
+// Returns:
+//   - *APIValidationSettings: Validation settings found, nil if the method or version is not supported by the route
+func (er *endpointRoute) getSettings(method string, version string) *APIValidationSettings {
+	versions, found := er.methods[method]
+	if !found {
+		versions, found = er.methods[""]
+	}
+
+	if !found && method == "" {
+		versions = er.methods[er.firstMethod]
+	}
+
+	return selectEndpointVersion(versions, version)
+}
+
+// selectEndpointVersion returns the highest version that satisfies the version requested
+//
+// Parameters:
+//   - versions: Versions of the endpoint, the highest version first
+//   - version: Version or version range requested, empty for the highest version
+//
+// Returns:
+//   - *APIValidationSettings: Validation settings of the version found, nil if no version satisfies the request
+func selectEndpointVersion(versions []endpointVersion, version string) *APIValidationSettings {
+	if len(versions) == 0 {
+		return nil
+	} else if strings.TrimSpace(version) == "" {
+		return versions[0].settings
+	}
+
+	requested, valid := parseAPIVersion(version)
+	for _, candidate := range versions {
+		if valid && candidate.valid {
+			if matchesAPIVersion(candidate.version, requested) {
+				return candidate.settings
+			}
+		} else if strings.EqualFold(strings.TrimSpace(candidate.settings.APIVersion), strings.TrimSpace(version)) {
+			return candidate.settings
+		}
+	}
+
+	return nil
+}
+
+// addVersion adds the validation settings of a version to a method of the route, keeping the highest version first
+//
+// Parameters:
+//   - method: HTTP method of the endpoint, empty for any method
+//   - settings: Validation settings of the version
+//
+// Returns:
+//   - bool: false if the version was already configured for the method
+func (er *endpointRoute) addVersion(method string, settings *APIValidationSettings) bool {
+	if len(er.methods) == 0 {
+		er.firstMethod = method
+	}
+
+	versions := er.methods[method]
+	for _, existing := range versions {
+		if strings.EqualFold(strings.TrimSpace(existing.settings.APIVersion), strings.TrimSpace(settings.APIVersion)) {
+			return false
+		}
+	}
+
+	newVersion := endpointVersion{settings: settings}
+	newVersion.version, newVersion.valid = parseAPIVersion(settings.APIVersion)
+	versions = append(versions, newVersion)
+
+	// Versions that can not be parsed are kept at the end, in the configuration order
+	sort.SliceStable(versions, func(a, b int) bool {
+		if versions[a].valid != versions[b].valid {
+			return versions[a].valid
+		}
+
+		return versions[a].valid && compareAPIVersions(versions[a].version, versions[b].version) > 0
+	})
+
+	er.methods[method] = versions
+	return true
+}
+
+// isPathParameter indicates if a path segment is a parameter ({consentId})
+//
+// Parameters:
+//   - segment: Path segment
+//
+// Returns:
+//   - bool: true if the segment is a parameter
+func isPathParameter(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// matches indicates if the route matches the path segments
+//
+// Parameters:
+//   - segments: Path segments of the endpoint name in lower case
+//
+// Returns:
+//   - bool: true if all the literal segments are equal and all the parameters have a value
+func (er *endpointRoute) matches(segments []string) bool {
+	if len(segments) != len(er.segments) {
+		return false
+	}
+
+	for i, segment := range er.segments {
+		if isPathParameter(segment) {
+			if segments[i] == "" {
+				return false
+			}
+		} else if segment != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isMoreSpecific indicates if the route takes precedence over another one with the same number of segments:
+// the first literal segment where the other has a parameter wins, then the longest endpoint base,
+// then the first one in the configuration
+//
+// Parameters:
+//   - other: Route to compare with
+//
+// Returns:
+//   - bool: true if this route takes precedence
+func (er *endpointRoute) isMoreSpecific(other *endpointRoute) bool {
+	for i := range er.segments {
+		thisLiteral, otherLiteral := !isPathParameter(er.segments[i]), !isPathParameter(other.segments[i])
+		if thisLiteral != otherLiteral {
+			return thisLiteral
+		}
+	}
+
+	if er.baseLength != other.baseLength {
+		return er.baseLength > other.baseLength
+	}
+
+	return er.order < other.order
+}
+
+// EndpointIndex resolves endpoint names and request paths to their validation settings, it is built once by
+// configuration version and must not be modified after it is built
+type EndpointIndex struct {
+	exact     map[string]*endpointRoute // Routes by endpoint name (base + endpoint) in lower case
+	templates []*endpointRoute          // Routes with path parameters, sorted by precedence
+}
+
+// normalizeEndpointName returns the name used to look up an endpoint in the index
+//
+// Parameters:
+//   - endpointName: Name of the endpoint, or request path
+//
+// Returns:
+//   - string: Endpoint name in lower case, without spaces, query string or trailing slash
+func normalizeEndpointName(endpointName string) string {
+	name := strings.ToLower(strings.TrimSpace(endpointName))
+	if index := strings.IndexByte(name, '?'); index >= 0 {
+		name = name[:index]
+	}
+
+	if len(name) > 1 {
+		name = strings.TrimSuffix(name, "/")
+	}
+
+	return name
+}
+
+// newEndpointIndex builds the endpoint index of the validation settings. When the same endpoint name, method and
+// API version are configured more than once, the first one in the configuration is used
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - settings: Validation settings with the endpoints to index
+//
+// Returns:
+//   - *EndpointIndex: Index built
+func newEndpointIndex(logger log.Logger, settings *models.ValidationSettings) *EndpointIndex {
+	index := &EndpointIndex{exact: make(map[string]*endpointRoute)}
+	for i := range settings.APIGroupSettings {
+		group := &settings.APIGroupSettings[i]
+		for j := range group.APIList {
+			api := &group.APIList[j]
+			endpointBase := strings.TrimSpace(api.EndpointBase)
+			for k := range api.EndpointList {
+				endpoint := &api.EndpointList[k]
+				validationSettings := &APIValidationSettings{
+					EndpointSettings: endpoint,
+					EndpointName:     endpointBase + strings.TrimSpace(endpoint.Endpoint),
+					APIVersion:       api.Version,
+					API:              api.API,
+					APIGroup:         group.Group,
+					BasePath:         api.BasePath,
+				}
+
+				name := normalizeEndpointName(validationSettings.EndpointName)
+				route, found := index.exact[name]
+				if !found {
+					route = &endpointRoute{
+						segments:   strings.Split(name, "/"),
+						baseLength: len(endpointBase),
+						order:      len(index.exact),
+						methods:    make(map[string][]endpointVersion),
+					}
+
+					index.exact[name] = route
+					if strings.Contains(name, "{") {
+						index.templates = append(index.templates, route)
+					}
+				}
+
+				method := strings.ToUpper(strings.TrimSpace(endpoint.Method))
+				if !route.addVersion(method, validationSettings) {
+					logger.Warning("Endpoint configured more than once, using the first one: "+method+" "+name+" "+api.Version, "EndpointIndex", "newEndpointIndex")
+				}
+			}
+		}
+	}
+
+	// Only routes with the same number of segments can match the same path
+	sort.Slice(index.templates, func(a, b int) bool {
+		if len(index.templates[a].segments) != len(index.templates[b].segments) {
+			return len(index.templates[a].segments) < len(index.templates[b].segments)
+		}
+
+		return index.templates[a].isMoreSpecific(index.templates[b])
+	})
+
+	return index
+}
+
+// Lookup returns the validation settings of an endpoint, exact names are resolved first and then the routes
+// with path parameters are checked by precedence, skipping the routes that do not support the method or version
+//
+// Parameters:
+//   - endpointName: Name of the endpoint, or request path
+//   - method: HTTP method of the request, empty if it is not known
+//   - version: Version or version range requested (2.1.0, 2.x), empty for the highest version
+//
+// Returns:
+//   - *APIValidationSettings: Validation settings of the endpoint, nil if it is not supported
+func (ei *EndpointIndex) Lookup(endpointName string, method string, version string) *APIValidationSettings {
+	name := normalizeEndpointName(endpointName)
+	method = strings.ToUpper(method)
+	if route, found := ei.exact[name]; found {
+		if settings := route.getSettings(method, version); settings != nil {
+			return settings
+		}
+	}
+
+	if len(ei.templates) == 0 {
+		return nil
+	}
+
+	segments := strings.Split(name, "/")
+	for _, route := range ei.templates {
+		if route.matches(segments) {
+			if settings := route.getSettings(method, version); settings != nil {
+				return settings
+			}
+		}
+	}
+
+	return nil
+}
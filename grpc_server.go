@@ -0,0 +1,323 @@
+package application
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/application/ingestionpb"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const grpcRoute = "GRPC" // Name of the route used for the authentication of the gRPC requests
+
+// GRPCServer exposes the gRPC ingestion API described in validation_service.proto, the messages are processed the same
+// way as the HTTP API
+type GRPCServer struct {
+	ingestionpb.UnimplementedValidationServiceServer
+	pack   string                // Package name
+	logger log.Logger            // Logger to be used
+	as     *APIServer            // API server used to validate and enqueue the messages
+	cm     *ConfigurationManager // Manager for application settings
+	server *grpc.Server          // gRPC server, nil until StartServing is called
+	health *health.Server        // Health checking service
+}
+
+// GetGRPCServer Creates a new GRPCServer
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - as: API server used to validate and enqueue the messages
+//   - cm: ConfigurationManager to handle the configuration
+//
+// Returns:
+//   - *GRPCServer: GRPCServer created
+func GetGRPCServer(logger log.Logger, as *APIServer, cm *ConfigurationManager) *GRPCServer {
+	return &GRPCServer{
+		pack:   "GRPC",
+		logger: logger,
+		as:     as,
+		cm:     cm,
+		health: health.NewServer(),
+	}
+}
+
+// StartServing Starts the gRPC server on the configured port, with health checking and reflection enabled
+//
+// Parameters:
+//
+// Returns:
+func (gs *GRPCServer) StartServing() {
+	port := strings.Replace(gs.cm.GetGRPCPort(), ":", "", -1)
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		gs.logger.Fatal(err, "Error listening on the gRPC port", gs.pack, "StartServing")
+	}
+
+	options := []grpc.ServerOption{
+		grpc.UnaryInterceptor(gs.unaryAuthentication),
+		grpc.StreamInterceptor(gs.streamAuthentication),
+	}
+
+	if gs.cm.IsHTTPS() {
+		tlsConfig, err := gs.getTLSConfig()
+		if err != nil {
+			gs.logger.Fatal(err, "Error loading the gRPC certificates", gs.pack, "StartServing")
+		}
+
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	gs.server = grpc.NewServer(options...)
+	ingestionpb.RegisterValidationServiceServer(gs.server, gs)
+	healthpb.RegisterHealthServer(gs.server, gs.health)
+	reflection.Register(gs.server)
+	gs.health.SetServingStatus(ingestionpb.ValidationService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	gs.logger.Log("Starting the gRPC server on port "+port, gs.pack, "StartServing")
+	err = gs.server.Serve(listener)
+	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		gs.logger.Fatal(err, "", gs.pack, "StartServing")
+	}
+}
+
+// Shutdown marks the service as not serving, stops accepting new requests and waits for the active ones to finish
+//
+// Parameters:
+//   - ctx: Context with the deadline for the shutdown
+//
+// Returns:
+//   - error: error if the active requests did not finish before the deadline
+func (gs *GRPCServer) Shutdown(ctx context.Context) error {
+	if gs.server == nil {
+		return nil
+	}
+
+	gs.logger.Info("Stopping the gRPC server", gs.pack, "Shutdown")
+	gs.health.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		gs.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		gs.server.Stop()
+		return ctx.Err()
+	}
+}
+
+// getTLSConfig returns the TLS configuration of the gRPC server, using the same certificates as the HTTP server
+//
+// Returns:
+//   - *tls.Config: TLS configuration
+//   - error: error if the certificates could not be loaded
+func (gs *GRPCServer) getTLSConfig() (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(gs.cm.GetCertFilePath(), gs.cm.GetKeyFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := gs.as.getServerTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	tlsConfig.Certificates = []tls.Certificate{certificate}
+	return tlsConfig, nil
+}
+
+// authenticate validates the request with the methods configured for the GRPC route, the metadata is read as headers.
+// HMAC is not supported as the signature is calculated over the raw HTTP body
+//
+// Parameters:
+//   - ctx: Context of the request
+//
+// Returns:
+//   - error: Unauthenticated status if none of the configured methods succeeds
+func (gs *GRPCServer) authenticate(ctx context.Context) error {
+	authenticators := gs.as.getAuthenticators(grpcRoute)
+	if len(authenticators) == 0 {
+		return nil
+	}
+
+	request := &http.Request{Header: http.Header{}}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if ok {
+		tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+		if ok {
+			request.TLS = &tlsInfo.State
+		}
+	}
+
+	for method, authenticator := range authenticators {
+		err := authenticator.Authenticate(request, nil)
+		if err == nil {
+			return nil
+		}
+
+		gs.logger.Debug(method+" authentication failed: "+err.Error(), gs.pack, "authenticate")
+	}
+
+	monitoring.IncreaseRejectedAuthentications(grpcRoute)
+	return status.Error(codes.Unauthenticated, "Unauthorized.")
+}
+
+// unaryAuthentication authenticates the unary requests
+func (gs *GRPCServer) unaryAuthentication(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// streamAuthentication authenticates the streaming requests
+func (gs *GRPCServer) streamAuthentication(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := gs.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
+
+// getBatchItem maps a request to a BatchItem, so it is processed the same way as the HTTP messages
+//
+// Parameters:
+//   - request: Request received
+//
+// Returns:
+//   - *BatchItem: Message with the values of the request
+func (gs *GRPCServer) getBatchItem(request *ingestionpb.ValidateResponseRequest) *BatchItem {
+	item := &BatchItem{
+		ServerOrgID:        request.GetServerOrgId(),
+		XFapiInteractionID: request.GetXFapiInteractionId(),
+		EndpointName:       request.GetEndpointName(),
+		Version:            request.GetVersion(),
+		ConsentID:          request.GetConsentId(),
+		TransmitterID:      request.GetTransmitterId(),
+		RequestPath:        request.GetRequestPath(),
+		HTTPMethod:         request.GetHttpMethod(),
+		Body:               json.RawMessage(request.GetBody()),
+	}
+
+	if len(request.GetResponseHeaders()) > 0 {
+		item.ResponseHeaders = request.GetResponseHeaders()
+	}
+
+	return item
+}
+
+// getReply creates the reply message with the result of a message
+//
+// Parameters:
+//   - result: Result of the message
+//
+// Returns:
+//   - *ingestionpb.ValidateResponseReply: Reply with the result
+func (gs *GRPCServer) getReply(result BatchItemResult) *ingestionpb.ValidateResponseReply {
+	return &ingestionpb.ValidateResponseReply{
+		Index:              int32(result.Index),
+		XFapiInteractionId: result.XFapiInteractionID,
+		Accepted:           result.Accepted,
+		Message:            result.Message,
+		RetryAfter:         int32(result.RetryAfter),
+	}
+}
+
+// ValidateResponse validates and enqueues a single message, rejected messages are returned as error status
+//
+// Parameters:
+//   - ctx: Context of the request
+//   - request: Request received
+//
+// Returns:
+//   - *ingestionpb.ValidateResponseReply: Reply with the result of the message
+//   - error: InvalidArgument status if the message is not valid, Unavailable if the queue is full
+func (gs *GRPCServer) ValidateResponse(_ context.Context, request *ingestionpb.ValidateResponseRequest) (*ingestionpb.ValidateResponseReply, error) {
+	result := gs.as.processBatchItem(gs.getBatchItem(request), http.MethodPost)
+	if !result.Accepted {
+		if result.RetryAfter > 0 {
+			return nil, status.Error(codes.Unavailable, result.Message+" Retry after "+strconv.Itoa(result.RetryAfter)+" seconds.")
+		}
+
+		return nil, status.Error(codes.InvalidArgument, result.Message)
+	}
+
+	return gs.getReply(result), nil
+}
+
+// ValidateResponses validates and enqueues the messages of a client stream, the messages are enqueued once the stream
+// is closed, so no message is enqueued if the stream is rejected
+//
+// Parameters:
+//   - stream: Stream of messages
+//
+// Returns:
+//   - error: error if the stream could not be read
+func (gs *GRPCServer) ValidateResponses(stream grpc.ClientStreamingServer[ingestionpb.ValidateResponseRequest, ingestionpb.ValidateResponsesReply]) error {
+	items := make([]*BatchItem, 0)
+	for index := 0; ; index++ {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if index >= maxBatchItems {
+			return status.Error(codes.ResourceExhausted, "Max number of messages exceeded ("+strconv.Itoa(maxBatchItems)+").")
+		}
+
+		items = append(items, gs.getBatchItem(request))
+	}
+
+	reply := &ingestionpb.ValidateResponsesReply{Results: make([]*ingestionpb.ValidateResponseReply, 0, len(items))}
+	for index, item := range items {
+		result := gs.as.processBatchItem(item, http.MethodPost)
+		result.Index = index
+		if result.Accepted {
+			reply.Accepted++
+		} else {
+			reply.Rejected++
+		}
+
+		reply.Results = append(reply.Results, gs.getReply(result))
+	}
+
+	return stream.SendAndClose(reply)
+}
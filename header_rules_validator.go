@@ -0,0 +1,150 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+// HeaderRule contains the rule to validate a header, all the conditions set must be met
+type HeaderRule struct {
+	Required  bool     `json:"required"`  // Indicates the header must be present
+	Pattern   string   `json:"pattern"`   // Regular expression the value must match
+	Enum      []string `json:"enum"`      // Values allowed for the header
+	MaxLength int      `json:"maxLength"` // Max length of the value, 0 if there is no limit
+}
+
+// compiledHeaderRule stores a header rule with its pattern compiled
+type compiledHeaderRule struct {
+	HeaderRule
+	pattern *regexp.Regexp // Compiled pattern, nil if the rule has no pattern
+}
+
+// HeaderRulesValidator Validator that uses the header validation rules of an endpoint. The rules are a JSON object
+// with the header name (case insensitive) as key and a HeaderRule as value, for example:
+//
+//	{"content-type": {"required": true, "pattern": "^application/json"}, "x-fapi-interaction-id": {"required": true}}
+type HeaderRulesValidator struct {
+	pack   string                         // Package name
+	rules  map[string]*compiledHeaderRule // Rules by lower case header name
+	logger log.Logger                     // Logger
+}
+
+// GetHeaderRulesValidator is for creating a HeaderRulesValidator
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - rules: Header validation rules of the endpoint
+//
+// Returns:
+//   - *HeaderRulesValidator: HeaderRulesValidator instance
+//   - error: error if the rules are not a valid JSON object or a pattern can not be compiled
+func GetHeaderRulesValidator(logger log.Logger, rules string) (*HeaderRulesValidator, error) {
+	var headerRules map[string]HeaderRule
+	err := json.Unmarshal([]byte(rules), &headerRules)
+	if err != nil {
+		return nil, errors.New("invalid header validation rules: " + err.Error())
+	}
+
+	compiled := make(map[string]*compiledHeaderRule, len(headerRules))
+	for name, rule := range headerRules {
+		compiledRule := &compiledHeaderRule{HeaderRule: rule}
+		if rule.Pattern != "" {
+			compiledRule.pattern, err = regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, errors.New("invalid pattern for header " + name + ": " + err.Error())
+			}
+		}
+
+		compiled[strings.ToLower(name)] = compiledRule
+	}
+
+	return &HeaderRulesValidator{
+		pack:   "HeaderRulesValidator",
+		rules:  compiled,
+		logger: logger,
+	}, nil
+}
+
+// Validate is for Validating the headers of a message using the header rules, the header names of data must be in
+// lower case
+//
+// Parameters:
+//   - data: Headers to be validated, nil if the message has no headers
+//
+// Returns:
+//   - *Result: Result of the validation, the errors are reported by header name
+//   - error: error if the validation could not be executed
+func (hv *HeaderRulesValidator) Validate(data DynamicStruct) (*Result, error) {
+	hv.logger.Info("Starting Validation With Header Rules", hv.pack, "Validate")
+
+	validationResult := Result{Valid: true, Errors: make(map[string][]string)}
+	names := make([]string, 0, len(hv.rules))
+	for name := range hv.rules {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		rule := hv.rules[name]
+		value, found := data[name]
+		if !found {
+			if rule.Required {
+				validationResult.Errors[name] = append(validationResult.Errors[name], "is required")
+			}
+
+			continue
+		}
+
+		for _, description := range rule.check(fmt.Sprint(value)) {
+			validationResult.Errors[name] = append(validationResult.Errors[name], description)
+		}
+	}
+
+	for name, descriptions := range validationResult.Errors {
+		validationResult.Valid = false
+		hv.logger.Debug(name+": "+strings.Join(descriptions, ", "), hv.pack, "Validate")
+	}
+
+	return &validationResult, nil
+}
+
+// check validates a header value against the rule
+//
+// Parameters:
+//   - value: Value of the header
+//
+// Returns:
+//   - []string: Description of the conditions not met, empty if the value is valid
+func (cr *compiledHeaderRule) check(value string) []string {
+	descriptions := make([]string, 0)
+	if cr.MaxLength > 0 && len(value) > cr.MaxLength {
+		descriptions = append(descriptions, "String length must be less than or equal to "+strconv.Itoa(cr.MaxLength))
+	}
+
+	if cr.pattern != nil && !cr.pattern.MatchString(value) {
+		descriptions = append(descriptions, "Does not match pattern '"+cr.Pattern+"'")
+	}
+
+	if len(cr.Enum) > 0 {
+		allowed := false
+		for _, option := range cr.Enum {
+			if option == value {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			descriptions = append(descriptions, "Must be one of the following: "+strings.Join(cr.Enum, ", "))
+		}
+	}
+
+	return descriptions
+}
@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+// testHeaderRules are the header rules used on the tests
+const testHeaderRules = `{
+	"Content-Type": {"required": true, "enum": ["application/json", "application/json; charset=utf-8"]},
+	"x-fapi-interaction-id": {"required": true, "pattern": "^[0-9a-fA-F-]{36}$"},
+	"x-v": {"maxLength": 5}
+}`
+
+func TestHeaderRulesValidator(t *testing.T) {
+	logger := log.GetLogger()
+	logger.SetLoggingGlobalLevel(log.ErrorLevel)
+	val, err := GetHeaderRulesValidator(logger, testHeaderRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := val.Validate(DynamicStruct{"content-type": "application/json", "x-fapi-interaction-id": "a3b2c1d0-0000-4000-8000-000000000001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Valid {
+		t.Fatalf("expected valid headers, errors: %v", result.Errors)
+	}
+
+	result, err = val.Validate(DynamicStruct{"content-type": "text/html", "x-v": "1.0.0-rc1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Valid {
+		t.Fatal("expected invalid headers")
+	}
+
+	for _, name := range []string{"content-type", "x-fapi-interaction-id", "x-v"} {
+		if len(result.Errors[name]) != 1 {
+			t.Errorf("expected one error for %s, found: %v", name, result.Errors[name])
+		}
+	}
+
+	result, err = val.Validate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Valid || len(result.Errors) != 2 {
+		t.Errorf("expected the required headers to be reported without headers, found: %v", result.Errors)
+	}
+}
+
+func TestHeaderRulesValidatorInvalidRules(t *testing.T) {
+	for _, rules := range []string{`[]`, `{"content-type": {"pattern": "("}}`} {
+		if _, err := GetHeaderRulesValidator(log.GetLogger(), rules); err == nil {
+			t.Errorf("expected error for rules: %s", rules)
+		}
+	}
+}
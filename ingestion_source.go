@@ -0,0 +1,87 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+)
+
+// MessageIngestor receives the messages read by the ingestion sources
+type MessageIngestor interface {
+	// IngestMessage validates the values of a message and queues it, onProcessed is called once the message is
+	// processed or discarded, it is not called if the message could not be queued because the context was cancelled
+	IngestMessage(ctx context.Context, header http.Header, body []byte, onProcessed func()) error
+}
+
+// IngestionSource is the Interface for the sources that feed the message queue besides the HTTP and gRPC APIs
+type IngestionSource interface {
+	Name() string                                              // Name of the source
+	Start(ctx context.Context, ingestor MessageIngestor) error // Reads messages until the context is cancelled
+	Close(ctx context.Context) error                           // Confirms the processed messages and releases the source
+}
+
+// GetIngestionSources returns the ingestion sources enabled on the settings
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - cm: Configuration manager with the source settings
+//
+// Returns:
+//   - []IngestionSource: Sources enabled
+//   - error: error if a source could not be created
+func GetIngestionSources(logger log.Logger, cm *ConfigurationManager) ([]IngestionSource, error) {
+	sources := make([]IngestionSource, 0)
+	if cm.settings.KafkaSettings.Enabled {
+		source, err := NewKafkaSource(logger, cm.settings.KafkaSettings)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// IngestMessage validates the values of a message read by an ingestion source and queues it, the message is
+// selected with the same validation rate as the HTTP messages, and the source waits while the queue is full
+//
+// Parameters:
+//   - ctx: Context to stop waiting for room in the queue
+//   - header: Values of the message, with the same names as the /ValidateResponse headers
+//   - body: Body of the message
+//   - onProcessed: Called once the message is processed or discarded
+//
+// Returns:
+//   - error: error if the message is not valid, or the context error if it could not be queued
+func (as *APIServer) IngestMessage(ctx context.Context, header http.Header, body []byte, onProcessed func()) error {
+	monitoring.IncreaseRequestsReceived()
+	var msg Message
+	loadError := as.loadMessageHeaderValues(header, &msg)
+	if loadError != nil {
+		onProcessed()
+		return errors.New(loadError.Message)
+	}
+
+	validationSettings, checkError := as.checkMessage(&msg, body)
+	if checkError != nil {
+		onProcessed()
+		return errors.New(checkError.Message)
+	}
+
+	if !as.mustValidate(&msg, validationSettings.EndpointSettings) {
+		onProcessed()
+		return nil
+	}
+
+	msg.Message = string(body)
+	if msg.HTTPMethod == "" {
+		msg.HTTPMethod = http.MethodPost
+	}
+
+	msg.onAcknowledge = onProcessed
+	return as.qm.EnqueueMessageWait(ctx, &msg)
+}
@@ -0,0 +1,5 @@
+// Package ingestionpb contains the messages and service stubs of the gRPC ingestion API, generated from
+// validation_service.proto
+package ingestionpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative validation_service.proto
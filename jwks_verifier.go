@@ -0,0 +1,244 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	jwksCacheTTL         = 1 * time.Hour   // Time the key set is used before fetching it again
+	jwksMinRefreshPeriod = 1 * time.Minute // Minimum time between fetches triggered by an unknown kid
+)
+
+// KeySetFetcher returns the JSON Web Key Set published by the authorization server
+type KeySetFetcher func(ctx context.Context) ([]byte, error)
+
+// jsonWebKey is a key of a JSON Web Key Set
+type jsonWebKey struct {
+	Kty string `json:"kty"` // Key type (RSA / EC)
+	Kid string `json:"kid"` // Key identifier
+	Use string `json:"use"` // Intended use of the key
+	N   string `json:"n"`   // RSA modulus
+	E   string `json:"e"`   // RSA exponent
+	Crv string `json:"crv"` // EC curve
+	X   string `json:"x"`   // EC X coordinate
+	Y   string `json:"y"`   // EC Y coordinate
+}
+
+// JWKSVerifier verifies the signature, issuer and audience of access tokens using the key set of the authorization server
+type JWKSVerifier struct {
+	logger    log.Logger                  // Logger to be used
+	fetcher   KeySetFetcher               // Function used to fetch the key set
+	issuer    string                      // Expected issuer of the tokens
+	audience  string                      // Expected audience of the tokens
+	clockSkew time.Duration               // Tolerance for differences between the client and server clocks
+	mutex     sync.Mutex                  // Mutex for thread-safe access to the keys
+	keys      map[string]crypto.PublicKey // Keys of the key set by kid
+	fetchedAt time.Time                   // Last time the key set was fetched
+}
+
+// NewJWKSVerifier creates a new verifier
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - fetcher: Function used to fetch the key set
+//   - issuer: Expected issuer of the tokens
+//   - audience: Expected audience of the tokens
+//   - clockSkew: Tolerance for differences between the client and server clocks
+//
+// Returns:
+//   - *JWKSVerifier: New verifier
+func NewJWKSVerifier(logger log.Logger, fetcher KeySetFetcher, issuer string, audience string, clockSkew time.Duration) *JWKSVerifier {
+	return &JWKSVerifier{
+		logger:    logger,
+		fetcher:   fetcher,
+		issuer:    issuer,
+		audience:  audience,
+		clockSkew: clockSkew,
+		keys:      make(map[string]crypto.PublicKey),
+	}
+}
+
+// Verify verifies the signature, issuer, audience and expiration of an access token
+//
+// Parameters:
+//   - ctx: Context of the request used to fetch the key set
+//   - accessToken: Token to be verified
+//
+// Returns:
+//   - error: error if the token is not valid
+func (v *JWKSVerifier) Verify(ctx context.Context, accessToken string) error {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "PS256", "ES256"}),
+		jwt.WithLeeway(v.clockSkew),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+	}
+
+	_, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.getKey(ctx, kid)
+	}, options...)
+	if err != nil {
+		v.logger.Error(err, "Access token verification failed", "jwt", "Verify")
+		return err
+	}
+
+	return nil
+}
+
+// getKey returns the key for a kid, the key set is fetched again if the kid is not found
+//
+// Parameters:
+//   - ctx: Context of the request used to fetch the key set
+//   - kid: Identifier of the key
+//
+// Returns:
+//   - crypto.PublicKey: Key found
+//   - error: error if the key is not found
+func (v *JWKSVerifier) getKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	expired := time.Since(v.fetchedAt) > jwksCacheTTL
+	key, found := v.keys[kid]
+	if found && !expired {
+		return key, nil
+	}
+
+	// Keys are rotated by the server, an unknown kid triggers a new fetch limited to one per period
+	if expired || time.Since(v.fetchedAt) > jwksMinRefreshPeriod {
+		err := v.fetchKeys(ctx)
+		if err != nil {
+			if found {
+				v.logger.Warning("Error fetching key set, using cached key", "jwt", "getKey")
+				return key, nil
+			}
+
+			return nil, err
+		}
+
+		key, found = v.keys[kid]
+	}
+
+	if !found {
+		return nil, errors.New("key not found in key set: " + kid)
+	}
+
+	return key, nil
+}
+
+// fetchKeys fetches and parses the key set
+//
+// Parameters:
+//   - ctx: Context of the request
+//
+// Returns:
+//   - error: error if any
+func (v *JWKSVerifier) fetchKeys(ctx context.Context) error {
+	v.logger.Info("Fetching key set", "jwt", "fetchKeys")
+	data, err := v.fetcher(ctx)
+	if err != nil {
+		return err
+	}
+
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	err = json.Unmarshal(data, &keySet)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := parseJSONWebKey(jwk)
+		if err != nil {
+			v.logger.Warning("Ignoring key "+jwk.Kid+": "+err.Error(), "jwt", "fetchKeys")
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return errors.New("no signing keys found in key set")
+	}
+
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.logger.Debug("Keys loaded: "+strconv.Itoa(len(keys)), "jwt", "fetchKeys")
+	return nil
+}
+
+// parseJSONWebKey creates the public key of a JSON Web Key
+//
+// Parameters:
+//   - jwk: JSON Web Key
+//
+// Returns:
+//   - crypto.PublicKey: Public key
+//   - error: error if the key type is not supported or the key is not valid
+func parseJSONWebKey(jwk jsonWebKey) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, errors.New("curve not supported: " + jwk.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("invalid EC key")
+		}
+
+		return key, nil
+	}
+
+	return nil, errors.New("key type not supported: " + jwk.Kty)
+}
@@ -8,12 +8,12 @@ import (
 	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // JWKToken Struct that stores the properties oj a JWT token
 type JWKToken struct {
 	AccessToken      string `json:"access_token"`       // Access token to be used
+	RefreshToken     string `json:"refresh_token"`      // Refresh token, empty if the server did not issue one
 	TokenType        string `json:"token_type"`         // Type of token
 	ExpiresIn        int    `json:"expires_in"`         // Indicates the expiration of the token
 	RefreshExpiresIn int    `json:"refresh_expires_in"` // Indicates the expiration of the refresh token
@@ -37,22 +37,16 @@ func ValidateExpiration(logger log.Logger, token *JWKToken) bool {
 		return false
 	}
 
-	parsedToken, _, err := jwt.NewParser().ParseUnverified(token.AccessToken, jwt.MapClaims{})
-	if err != nil {
-		logger.Error(err, "Error parsing or validating token", "jwt", "validateExpiration")
+	expirationTime, ok := getExpirationTime(token.AccessToken)
+	if !ok {
+		logger.Info("Invalid JWT token", "jwt", "validateExpiration")
 		return false
 	}
 
-	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok {
-		expirationTime := time.Unix(int64(claims["exp"].(float64)), 0)
-		logger.Debug("Token expiration time: "+expirationTime.String(), "jwt", "ValidateToken")
-		currentTime := time.Now()
-		if currentTime.After(expirationTime) {
-			logger.Info("jwt token has expired", "jwt", "validateExpiration")
-			return false
-		}
-	} else {
-		logger.Info("Invalid JWT token", "jwt", "validateExpiration")
+	logger.Debug("Token expiration time: "+expirationTime.String(), "jwt", "ValidateToken")
+	// The token is considered expired a bit earlier to tolerate differences between the client and server clocks
+	if time.Now().Add(defaultClockSkew).After(expirationTime) {
+		logger.Info("jwt token has expired", "jwt", "validateExpiration")
 		return false
 	}
 
@@ -0,0 +1,309 @@
+package application
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// partitionKey identifies a partition of a topic
+type partitionKey struct {
+	topic     string // Name of the topic
+	partition int32  // Number of the partition
+}
+
+// partitionOffsets tracks the records of a partition until they are processed, the offsets are only marked for
+// commit when all the previous records of the partition were processed, as the workers finish them out of order
+type partitionOffsets struct {
+	pending []*kgo.Record  // Records not yet committed, in offset order
+	done    map[int64]bool // Offsets of the pending records already processed
+}
+
+// KafkaSource reads the messages to be validated from Kafka topics, using a consumer group
+type KafkaSource struct {
+	crosscutting.OFBStruct
+	client        *kgo.Client                        // Kafka client
+	headerMapping map[string]string                  // Record header names by message header name
+	offsets       map[partitionKey]*partitionOffsets // Offsets tracked by partition
+	offsetsMutex  sync.Mutex                         // Mutex for the offsets
+}
+
+// NewKafkaSource creates a new Kafka ingestion source, the offsets are committed only after the messages are processed
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - settings: Kafka settings
+//
+// Returns:
+//   - *KafkaSource: Kafka source created
+//   - error: error if the client could not be created
+func NewKafkaSource(logger log.Logger, settings configuration.KafkaSettings) (*KafkaSource, error) {
+	ks := &KafkaSource{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.KafkaSource",
+			Logger: logger,
+		},
+		headerMapping: make(map[string]string),
+		offsets:       make(map[partitionKey]*partitionOffsets),
+	}
+
+	for messageHeader, recordHeader := range settings.HeaderMapping {
+		ks.headerMapping[strings.ToLower(recordHeader)] = messageHeader
+	}
+
+	options := []kgo.Opt{
+		kgo.SeedBrokers(settings.Brokers...),
+		kgo.ConsumerGroup(settings.GroupID),
+		kgo.ConsumeTopics(settings.Topics...),
+		kgo.ClientID(settings.ClientID),
+		kgo.AutoCommitMarks(),
+		kgo.OnPartitionsRevoked(ks.onPartitionsRevoked),
+		kgo.OnPartitionsLost(ks.onPartitionsLost),
+	}
+
+	if settings.EnableTLS {
+		tlsConfig, err := getKafkaTLSConfig(settings.CACertFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, kgo.DialTLSConfig(tlsConfig))
+	}
+
+	switch strings.ToUpper(settings.SASLMechanism) {
+	case "":
+	case "PLAIN":
+		options = append(options, kgo.SASL(plain.Auth{User: settings.SASLUsername, Pass: settings.SASLPassword}.AsMechanism()))
+	case "SCRAM-SHA-256":
+		options = append(options, kgo.SASL(scram.Auth{User: settings.SASLUsername, Pass: settings.SASLPassword}.AsSha256Mechanism()))
+	case "SCRAM-SHA-512":
+		options = append(options, kgo.SASL(scram.Auth{User: settings.SASLUsername, Pass: settings.SASLPassword}.AsSha512Mechanism()))
+	default:
+		return nil, errors.New("SASL mechanism not supported: " + settings.SASLMechanism)
+	}
+
+	client, err := kgo.NewClient(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	ks.client = client
+	return ks, nil
+}
+
+// getKafkaTLSConfig returns the TLS configuration for the brokers
+//
+// Parameters:
+//   - caFile: PEM CA bundle to verify the brokers, the system roots are used if empty
+//
+// Returns:
+//   - *tls.Config: TLS configuration
+//   - error: error if the CA bundle could not be loaded
+func getKafkaTLSConfig(caFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return tlsConfig, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(caFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.RootCAs = x509.NewCertPool()
+	if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificates found in Kafka CA bundle: " + caFile)
+	}
+
+	return tlsConfig, nil
+}
+
+// Name returns the name of the source
+//
+// Returns:
+//   - string: Name of the source
+func (ks *KafkaSource) Name() string {
+	return "kafka"
+}
+
+// Start reads the records of the topics and sends them to the ingestor until the context is cancelled
+//
+// Parameters:
+//   - ctx: Context to stop reading
+//   - ingestor: Ingestor of the messages
+//
+// Returns:
+//   - error: error if the client was closed
+func (ks *KafkaSource) Start(ctx context.Context, ingestor MessageIngestor) error {
+	ks.Logger.Log("Starting Kafka consumer", ks.Pack, "Start")
+	for {
+		fetches := ks.client.PollFetches(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if fetches.IsClientClosed() {
+			return kgo.ErrClientClosed
+		}
+
+		fetches.EachError(func(topic string, partition int32, err error) {
+			ks.Logger.Error(err, "Error fetching from "+topic+"["+strconv.Itoa(int(partition))+"]", ks.Pack, "Start")
+		})
+
+		iter := fetches.RecordIter()
+		for !iter.Done() {
+			ks.ingestRecord(ctx, ingestor, iter.Next())
+		}
+	}
+}
+
+// ingestRecord sends a record to the ingestor, invalid records are skipped
+//
+// Parameters:
+//   - ctx: Context to stop waiting for room in the queue
+//   - ingestor: Ingestor of the messages
+//   - record: Record read
+//
+// Returns:
+func (ks *KafkaSource) ingestRecord(ctx context.Context, ingestor MessageIngestor, record *kgo.Record) {
+	offsets := ks.track(record)
+	err := ingestor.IngestMessage(ctx, ks.getHeader(record), record.Value, func() {
+		ks.markProcessed(record, offsets)
+	})
+
+	if err != nil && ctx.Err() == nil {
+		ks.Logger.Warning("Record skipped, "+record.Topic+"["+strconv.Itoa(int(record.Partition))+"] offset "+
+			strconv.FormatInt(record.Offset, 10)+": "+err.Error(), ks.Pack, "ingestRecord")
+	}
+}
+
+// getHeader maps the record headers to the message headers, headers without mapping keep their name
+//
+// Parameters:
+//   - record: Record read
+//
+// Returns:
+//   - http.Header: Message headers
+func (ks *KafkaSource) getHeader(record *kgo.Record) http.Header {
+	header := http.Header{}
+	for _, recordHeader := range record.Headers {
+		name, ok := ks.headerMapping[strings.ToLower(recordHeader.Key)]
+		if !ok {
+			name = recordHeader.Key
+		}
+
+		header.Add(name, string(recordHeader.Value))
+	}
+
+	return header
+}
+
+// track adds a record to the offsets of its partition
+//
+// Parameters:
+//   - record: Record read
+//
+// Returns:
+//   - *partitionOffsets: Offsets of the partition of the record
+func (ks *KafkaSource) track(record *kgo.Record) *partitionOffsets {
+	ks.offsetsMutex.Lock()
+	defer ks.offsetsMutex.Unlock()
+
+	key := partitionKey{topic: record.Topic, partition: record.Partition}
+	offsets, ok := ks.offsets[key]
+	if !ok {
+		offsets = &partitionOffsets{done: make(map[int64]bool)}
+		ks.offsets[key] = offsets
+	}
+
+	offsets.pending = append(offsets.pending, record)
+	return offsets
+}
+
+// markProcessed marks a record as processed, and marks for commit the last record of the partition with all
+// the previous records processed
+//
+// Parameters:
+//   - record: Record processed
+//   - offsets: Offsets of the partition when the record was read
+//
+// Returns:
+func (ks *KafkaSource) markProcessed(record *kgo.Record, offsets *partitionOffsets) {
+	ks.offsetsMutex.Lock()
+	defer ks.offsetsMutex.Unlock()
+
+	// The partition was revoked after the record was read, it will be read again by the new owner
+	if ks.offsets[partitionKey{topic: record.Topic, partition: record.Partition}] != offsets {
+		return
+	}
+
+	offsets.done[record.Offset] = true
+	var last *kgo.Record
+	for len(offsets.pending) > 0 && offsets.done[offsets.pending[0].Offset] {
+		last = offsets.pending[0]
+		delete(offsets.done, last.Offset)
+		offsets.pending = offsets.pending[1:]
+	}
+
+	if last != nil {
+		ks.client.MarkCommitRecords(last)
+	}
+}
+
+// onPartitionsRevoked commits the marked offsets before the partitions are assigned to other consumer
+func (ks *KafkaSource) onPartitionsRevoked(ctx context.Context, client *kgo.Client, revoked map[string][]int32) {
+	err := client.CommitMarkedOffsets(ctx)
+	if err != nil {
+		ks.Logger.Error(err, "Error committing offsets of revoked partitions", ks.Pack, "onPartitionsRevoked")
+	}
+
+	ks.removePartitions(revoked)
+}
+
+// onPartitionsLost removes the lost partitions, their offsets can not be committed anymore
+func (ks *KafkaSource) onPartitionsLost(_ context.Context, _ *kgo.Client, lost map[string][]int32) {
+	ks.removePartitions(lost)
+}
+
+// removePartitions stops tracking the offsets of the partitions
+//
+// Parameters:
+//   - partitions: Partitions by topic
+//
+// Returns:
+func (ks *KafkaSource) removePartitions(partitions map[string][]int32) {
+	ks.offsetsMutex.Lock()
+	defer ks.offsetsMutex.Unlock()
+	for topic, numbers := range partitions {
+		for _, partition := range numbers {
+			delete(ks.offsets, partitionKey{topic: topic, partition: partition})
+		}
+	}
+}
+
+// Close commits the offsets of the processed records and leaves the consumer group
+//
+// Parameters:
+//   - ctx: Context with the deadline for the commit
+//
+// Returns:
+//   - error: error if the offsets could not be committed
+func (ks *KafkaSource) Close(ctx context.Context) error {
+	err := ks.client.CommitMarkedOffsets(ctx)
+	ks.client.Close()
+	return err
+}
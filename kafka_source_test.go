@@ -0,0 +1,198 @@
+package application
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const testTopic = "mqd-responses" // Topic used on the Kafka tests
+
+// testIngestor receives the messages read by the source, the messages are acknowledged by the test
+type testIngestor struct {
+	messages chan *Message // Messages received, in the order they were read
+}
+
+// IngestMessage keeps the message so the test can acknowledge it
+func (ti *testIngestor) IngestMessage(_ context.Context, _ http.Header, body []byte, onProcessed func()) error {
+	ti.messages <- &Message{Message: string(body), onAcknowledge: onProcessed}
+	return nil
+}
+
+// getCommittedOffset commits the marked offsets and returns the committed offset of the test partition, -1 if none
+func getCommittedOffset(t *testing.T, ks *KafkaSource) int64 {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := ks.client.CommitMarkedOffsets(ctx); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	offset, ok := ks.client.CommittedOffsets()[testTopic][0]
+	if !ok {
+		return -1
+	}
+
+	return offset.Offset
+}
+
+// startTestKafkaSource starts an in-memory cluster with 3 records on the test topic, and a source reading them into
+// the ingestor received
+func startTestKafkaSource(t *testing.T, ctx context.Context, ingestor MessageIngestor) *KafkaSource {
+	t.Helper()
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, testTopic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cluster.Close)
+
+	producer, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		record := &kgo.Record{Topic: testTopic, Value: []byte(`{"index":` + strconv.Itoa(i) + `}`)}
+		if err := producer.ProduceSync(ctx, record).FirstErr(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	producer.Close()
+
+	logger := log.GetLogger()
+	logger.SetLoggingGlobalLevel(log.ErrorLevel)
+	ks, err := NewKafkaSource(logger, configuration.KafkaSettings{
+		Enabled:  true,
+		Brokers:  cluster.ListenAddrs(),
+		Topics:   []string{testTopic},
+		GroupID:  "mqd-client-test",
+		ClientID: "mqd-client-test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sourceCtx, stopSource := context.WithCancel(ctx)
+	go func() {
+		_ = ks.Start(sourceCtx, ingestor)
+	}()
+	t.Cleanup(func() {
+		stopSource()
+		_ = ks.Close(context.Background())
+	})
+
+	return ks
+}
+
+// receiveTestMessages waits for the 3 records of the test topic
+func receiveTestMessages(t *testing.T, ctx context.Context, queue chan *Message) []*Message {
+	t.Helper()
+	messages := make([]*Message, 0, 3)
+	for len(messages) < 3 {
+		select {
+		case msg := <-queue:
+			messages = append(messages, msg)
+		case <-ctx.Done():
+			t.Fatalf("only %d messages received", len(messages))
+		}
+	}
+
+	return messages
+}
+
+func TestKafkaSourceCommitsAfterAcknowledge(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ingestor := &testIngestor{messages: make(chan *Message, 3)}
+	ks := startTestKafkaSource(t, ctx, ingestor)
+	messages := receiveTestMessages(t, ctx, ingestor.messages)
+
+	// Records read but not processed are not committed
+	if offset := getCommittedOffset(t, ks); offset > 0 {
+		t.Fatalf("offset committed before the messages were acknowledged: %d", offset)
+	}
+
+	qm := &QueueManager{}
+	// The second record finishes first, it can not be committed until the first one is processed
+	qm.Acknowledge(messages[1])
+	if offset := getCommittedOffset(t, ks); offset > 0 {
+		t.Fatalf("offset committed with a previous record pending: %d", offset)
+	}
+
+	qm.Acknowledge(messages[0])
+	if offset := getCommittedOffset(t, ks); offset != 2 {
+		t.Fatalf("expected committed offset 2, found %d", offset)
+	}
+
+	qm.Acknowledge(messages[2])
+	if offset := getCommittedOffset(t, ks); offset != 3 {
+		t.Fatalf("expected committed offset 3, found %d", offset)
+	}
+}
+
+// queueIngestor queues the messages read by the source on the queue manager, the same way as the API server
+type queueIngestor struct {
+	qm *QueueManager // Queue manager receiving the messages
+}
+
+// IngestMessage queues the message waiting for room in the queue
+func (qi *queueIngestor) IngestMessage(ctx context.Context, _ http.Header, body []byte, onProcessed func()) error {
+	return qi.qm.EnqueueMessageWait(ctx, &Message{Message: string(body), onAcknowledge: onProcessed})
+}
+
+func TestKafkaSourceWithPersistentQueue(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	logger := log.GetLogger()
+	directory := t.TempDir()
+	store, err := NewPersistentQueue(logger, directory, configuration.FsyncAlways, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qm := &QueueManager{
+		OFBStruct:    crosscutting.OFBStruct{Pack: "application.QueueManager", Logger: logger},
+		messageQueue: make(chan *Message, 3),
+		policy:       configuration.QueueReject,
+		store:        store,
+	}
+	ks := startTestKafkaSource(t, ctx, &queueIngestor{qm: qm})
+	messages := receiveTestMessages(t, ctx, qm.GetQueue())
+	store.Close()
+
+	// Kafka delivers the uncommitted records again after a crash, they must not be recovered from the disk too
+	recovered, err := NewPersistentQueue(logger, directory, configuration.FsyncAlways, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recovered.Close()
+
+	pending, err := recovered.Recover()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != 0 {
+		t.Fatalf("Kafka messages written to the persistent queue: %d", len(pending))
+	}
+
+	qm.store = recovered
+	for _, msg := range messages {
+		qm.Acknowledge(msg)
+	}
+
+	if offset := getCommittedOffset(t, ks); offset != 3 {
+		t.Fatalf("expected committed offset 3, found %d", offset)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
 	"github.com/OpenBanking-Brasil/MQD_Client/validation"
 )
 
@@ -22,17 +23,22 @@ var (
 	localResultMutex = sync.Mutex{} // Mutex for thread-safe access to messageResults
 	mu               = sync.Mutex{} // Mutex for thread-safe access to messageResults
 	basePath         = "./data_logs"
+
+	// sensitiveHeaders contains the response headers that are always masked before being stored
+	sensitiveHeaders = []string{"authorization", "proxy-authorization", "cookie", "set-cookie", "token", "secret", "api-key"}
 )
 
 type payloadDetail struct {
 	XFapiInteractionID string
 	ConsentID          string
 	Payload            validation.DynamicStruct
+	Headers            validation.DynamicStruct `json:",omitempty"`
 	Errors             map[string][]string
 }
 
 type localEndpointSummary struct {
 	EndpointName       string
+	Version            string
 	Requests           int
 	RequestsWithErrors int
 	PayloadDetails     []payloadDetail
@@ -45,6 +51,8 @@ type LocalResultManager struct {
 	result         map[string]localEndpointSummary
 	recordedErrors map[string]int
 	lstCleanupDate string
+	directory      string // Directory where the result files are stored
+	alwaysEnabled  bool   // Indicates the results are stored even if disabled on the settings (used by the replay)
 }
 
 // NewLocalResultManager creates a new Local result manager
@@ -64,9 +72,18 @@ func NewLocalResultManager(logger log.Logger, cm *ConfigurationManager) *LocalRe
 		cm:             cm,
 		result:         make(map[string]localEndpointSummary),
 		recordedErrors: make(map[string]int),
+		directory:      basePath,
 	}
 }
 
+// isEnabled indicates if the results must be stored
+//
+// Returns:
+//   - bool: true if the results must be stored
+func (mng *LocalResultManager) isEnabled() bool {
+	return mng.alwaysEnabled || mng.cm.settings.ResultSettings.Enabled
+}
+
 // StartResultProcess Start the process of storage and cleanup of files
 //
 // Parameters:
@@ -86,16 +103,17 @@ func (mng *LocalResultManager) StartResultProcess() {
 //
 // Returns:
 func (mng *LocalResultManager) AppendResult(message Message, result MessageResult, settings APIValidationSettings) {
-	if !mng.cm.settings.ResultSettings.Enabled {
+	if !mng.isEnabled() {
 		return
 	}
 
 	localResultMutex.Lock()
 
-	key := fmt.Sprintf("%s-%s-%s", settings.APIGroup, strings.ReplaceAll(settings.BasePath, "-", ""), settings.EndpointSettings.Endpoint)
+	key := fmt.Sprintf("%s-%s-%s-%s", settings.APIGroup, strings.ReplaceAll(settings.BasePath, "-", ""), settings.EndpointSettings.Endpoint, settings.APIVersion)
 	if _, ok := mng.result[key]; !ok {
 		mng.result[key] = localEndpointSummary{
 			EndpointName:       settings.EndpointSettings.Endpoint,
+			Version:            settings.APIVersion,
 			Requests:           0,
 			RequestsWithErrors: 0,
 			PayloadDetails:     make([]payloadDetail, 0),
@@ -110,7 +128,7 @@ func (mng *LocalResultManager) AppendResult(message Message, result MessageResul
 		needToSaveSample := false
 		for field, errorField := range result.Errors {
 			for _, validError := range errorField {
-				errorKey := fmt.Sprintf("%s-%s-%s-%s-%s", settings.APIGroup, strings.ReplaceAll(settings.BasePath, "-", ""), settings.EndpointSettings.Endpoint, field, validError)
+				errorKey := fmt.Sprintf("%s-%s-%s-%s-%s-%s", settings.APIGroup, strings.ReplaceAll(settings.BasePath, "-", ""), settings.EndpointSettings.Endpoint, settings.APIVersion, field, validError)
 				if mng.recordedErrors[errorKey] >= mng.cm.settings.ResultSettings.SamplesPerError {
 					continue
 				} else {
@@ -126,9 +144,18 @@ func (mng *LocalResultManager) AppendResult(message Message, result MessageResul
 				mng.Logger.Error(err, "there was an error while loading the message object", mng.Pack, "AppendResult")
 			}
 
-			payload = mng.findAndScrambleAttribute(payload)
+			securitySettings := &mng.cm.captureSnapshot(&message).Settings.SecuritySettings
+			payload = mng.findAndScrambleAttribute(payload, securitySettings)
+			headers, err := message.GetMappedHeaders()
+			if err != nil {
+				mng.Logger.Error(err, "there was an error while loading the message headers", mng.Pack, "AppendResult")
+			}
+
+			headers = mng.scrambleHeaders(headers, securitySettings)
+
 			newDetail := payloadDetail{
 				Payload:            payload,
+				Headers:            headers,
 				ConsentID:          message.ConsentID,
 				XFapiInteractionID: message.XFapiInteractionID,
 				Errors:             result.Errors,
@@ -141,6 +168,19 @@ func (mng *LocalResultManager) AppendResult(message Message, result MessageResul
 	localResultMutex.Unlock()
 }
 
+// Flush stores the results collected since the last stored file
+//
+// Parameters:
+//
+// Returns:
+func (mng *LocalResultManager) Flush() {
+	if !mng.isEnabled() {
+		return
+	}
+
+	mng.storeFiles()
+}
+
 func (mng *LocalResultManager) startStoreProcess() {
 	if !mng.cm.settings.ResultSettings.Enabled {
 		return
@@ -180,7 +220,7 @@ func (mng *LocalResultManager) storeFiles() {
 	}
 
 	for key, file := range filesToSave {
-		err := mng.saveFile(basePath, mng.cm.settings.ConfigurationSettings.ApplicationID.String(), key, file)
+		err := mng.saveFile(mng.directory, mng.cm.settings.ConfigurationSettings.ApplicationID.String(), key, file)
 		if err != nil {
 			mng.Logger.Error(err, "there was an error saving data file", mng.Pack, "storeFiles")
 		}
@@ -286,9 +326,9 @@ func (mng *LocalResultManager) saveFile(basePath string, appID string, familyTyp
 	return nil
 }
 
-func (mng *LocalResultManager) findAndScrambleAttribute(payload validation.DynamicStruct) validation.DynamicStruct {
+func (mng *LocalResultManager) findAndScrambleAttribute(payload validation.DynamicStruct, securitySettings *models.SecuritySettings) validation.DynamicStruct {
 	for k, v := range payload {
-		if mng.cm.ConfigurationSettings.SecuritySettings.HaveToMask(k) {
+		if securitySettings.HaveToMask(k) {
 			payload[k] = mng.scrambleValue(v) // Scramble the value
 			continue
 		}
@@ -296,12 +336,12 @@ func (mng *LocalResultManager) findAndScrambleAttribute(payload validation.Dynam
 		switch val := v.(type) {
 		case map[string]interface{}:
 			// Recurse into nested map
-			mng.findAndScrambleAttribute(val)
+			mng.findAndScrambleAttribute(val, securitySettings)
 		case []interface{}:
 			// Iterate over arrays of objects
 			for _, item := range val {
 				if nestedMap, ok := item.(map[string]interface{}); ok {
-					mng.findAndScrambleAttribute(nestedMap)
+					mng.findAndScrambleAttribute(nestedMap, securitySettings)
 				}
 			}
 		}
@@ -310,6 +350,32 @@ func (mng *LocalResultManager) findAndScrambleAttribute(payload validation.Dynam
 	return payload
 }
 
+// scrambleHeaders masks the response headers with credentials, and the ones configured to be masked
+//
+// Parameters:
+//   - headers: Response headers, with the names in lower case
+//   - securitySettings: Security settings with the attributes to mask
+//
+// Returns:
+//   - validation.DynamicStruct: Headers with the values masked
+func (mng *LocalResultManager) scrambleHeaders(headers validation.DynamicStruct, securitySettings *models.SecuritySettings) validation.DynamicStruct {
+	for name, value := range headers {
+		if securitySettings.HaveToMask(name) {
+			headers[name] = mng.scrambleValue(value)
+			continue
+		}
+
+		for _, sensitive := range sensitiveHeaders {
+			if strings.Contains(strings.ToLower(name), sensitive) {
+				headers[name] = mng.scrambleValue(value)
+				break
+			}
+		}
+	}
+
+	return headers
+}
+
 func (mng *LocalResultManager) scrambleValue(value interface{}) interface{} {
 	switch v := value.(type) {
 	case string:
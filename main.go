@@ -1,6 +1,13 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/OpenBanking-Brasil/MQD_Client/application"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
@@ -25,15 +32,15 @@ func init() {
 // @params
 // @return
 func main() {
-	reportServer := services.GetReportServer(logger, settings.SecuritySettings.ProxyURL, settings)
-	cm := application.NewConfigurationManager(logger, *reportServer, settings)
-	err := cm.Initialize()
-	if err != nil {
-		logger.Fatal(err, "There was a fatal error loading initial settings.", "Main", "Main")
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		replay(os.Args[2:])
+		return
 	}
 
-	qm := application.GetQueueManager()
-	rp := application.GetResultProcessor(logger, *reportServer, cm)
+	reportServer, cm := loadConfiguration()
+
+	qm := application.GetQueueManager(cm)
+	rp := application.GetResultProcessor(logger, *reportServer, cm, qm)
 	lrm := application.NewLocalResultManager(logger, cm)
 	mp := application.GetMessageProcessorWorker(logger, rp, qm, cm, lrm)
 
@@ -43,5 +50,141 @@ func main() {
 	go rp.StartResultsProcessor()
 	go lrm.StartResultProcess()
 
-	application.GetAPIServer(logger, monitoring.GetOpentelemetryHandler(), qm, cm).StartServing()
+	as := application.GetAPIServer(logger, monitoring.GetOpentelemetryHandler(), qm, cm, mp)
+	go as.StartServing()
+
+	var gs *application.GRPCServer
+	if cm.GetGRPCPort() != "" {
+		gs = application.GetGRPCServer(logger, as, cm)
+		go gs.StartServing()
+	}
+
+	sources, err := application.GetIngestionSources(logger, cm)
+	if err != nil {
+		logger.Fatal(err, "There was a fatal error loading the ingestion sources.", "Main", "Main")
+	}
+
+	sourcesCtx, stopSources := context.WithCancel(context.Background())
+	for _, source := range sources {
+		go func(source application.IngestionSource) {
+			err := source.Start(sourcesCtx, as)
+			if err != nil {
+				logger.Error(err, "Ingestion source stopped: "+source.Name(), "Main", "Main")
+			}
+		}(source)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	<-ctx.Done()
+
+	stopSources()
+	shutdown(cm, as, gs, sources, mp, rp, lrm, qm)
+}
+
+// loadConfiguration creates the report server and loads the validation settings
+//
+// Returns:
+//   - *services.ReportServer: Report server
+//   - *application.ConfigurationManager: Configuration manager with the settings loaded
+func loadConfiguration() (*services.ReportServer, *application.ConfigurationManager) {
+	// The proxy is only needed when the client certificates are not configured
+	serverURL := settings.SecuritySettings.ProxyURL
+	if settings.SecuritySettings.ServerURL != "" {
+		serverURL = settings.SecuritySettings.ServerURL
+	}
+
+	reportServer := services.GetReportServer(logger, serverURL, settings)
+	cm := application.NewConfigurationManager(logger, *reportServer, settings)
+	err := cm.Initialize()
+	if err != nil {
+		logger.Fatal(err, "There was a fatal error loading initial settings.", "Main", "Main")
+	}
+
+	return reportServer, cm
+}
+
+// replay validates captured responses (HAR or NDJSON files) with the current settings and stores a local summary,
+// the HTTP server is not started and no report is sent to the central server.
+// Usage: replay [-output directory] [-server-org-id id] file...
+//
+// Parameters:
+//   - args: Arguments of the subcommand
+//
+// Returns:
+func replay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	output := flags.String("output", "./replay_results", "Directory where the summary and the error samples are stored")
+	serverID := flags.String("server-org-id", "replay", "Server organization ID used when the messages do not include one")
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: replay [-output directory] [-server-org-id id] file.har|file.ndjson ...")
+		flags.PrintDefaults()
+		os.Exit(2)
+	}
+
+	_, cm := loadConfiguration()
+	replayer := application.NewReplayer(logger, cm, *output, *serverID)
+	for _, file := range flags.Args() {
+		err := replayer.ReplayFile(file)
+		if err != nil {
+			logger.Error(err, "Error replaying file: "+file, "Main", "replay")
+		}
+	}
+
+	path, err := replayer.WriteSummary()
+	if err != nil {
+		logger.Fatal(err, "Error writing the replay summary.", "Main", "replay")
+	}
+
+	logger.Info("Replay summary stored in: "+path, "Main", "replay")
+}
+
+// shutdown stops receiving messages, drains the queue and stores the pending results before exiting
+//
+// Parameters:
+//   - cm: Configuration manager
+//   - as: API server receiving the messages
+//   - gs: gRPC server receiving the messages, nil if disabled
+//   - sources: Ingestion sources, closed once the final report is sent so the processed messages are confirmed
+//   - mp: Message processor draining the queue
+//   - rp: Result processor to send the final report
+//   - lrm: Local result manager to store the final result files
+//   - qm: Queue manager
+//
+// Returns:
+func shutdown(cm *application.ConfigurationManager, as *application.APIServer, gs *application.GRPCServer, sources []application.IngestionSource, mp *application.MessageProcessorWorker, rp *application.ResultProcessor, lrm *application.LocalResultManager, qm *application.QueueManager) {
+	logger.Info("Stop signal received, shutting down", "Main", "shutdown")
+	ctx, cancel := context.WithTimeout(context.Background(), cm.GetShutdownGracePeriod())
+	defer cancel()
+
+	err := as.Shutdown(ctx)
+	if err != nil {
+		logger.Error(err, "Error stopping the server", "Main", "shutdown")
+	}
+
+	if gs != nil {
+		err = gs.Shutdown(ctx)
+		if err != nil {
+			logger.Error(err, "Error stopping the gRPC server", "Main", "shutdown")
+		}
+	}
+
+	err = mp.Stop(ctx)
+	if err != nil {
+		logger.Error(err, "The message queue was not drained before the grace period", "Main", "shutdown")
+	}
+
+	// The final report acknowledges the processed messages, it must be sent before the sources are closed
+	rp.Flush(ctx)
+	for _, source := range sources {
+		err = source.Close(ctx)
+		if err != nil {
+			logger.Error(err, "Error closing ingestion source: "+source.Name(), "Main", "shutdown")
+		}
+	}
+
+	lrm.Flush()
+	qm.Close()
+	logger.Info("Shutdown completed", "Main", "shutdown")
 }
@@ -1,18 +1,26 @@
 package application
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
-	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
 	"github.com/OpenBanking-Brasil/MQD_Client/validation"
 )
 
+const (
+	headerFieldPrefix = "header." // Prefix used on the error fields found during the header validation
+)
+
 var (
-	messageProcessorWorkerMutex = sync.Mutex{}          // Mutex for multiprocessing locks
+	messageProcessorWorkerMutex = sync.Mutex{}          // Mutex for the counters shared by the workers of the pool
 	singletonMutex              = sync.Mutex{}          // Mutex for the singleton variable
 	messageProcessorSingleton   *MessageProcessorWorker // Message process singleton
 )
@@ -26,6 +34,9 @@ type MessageProcessorWorker struct {
 	cm              *ConfigurationManager // Configuration manager
 	qm              *QueueManager         // Queue manager to queue the messages
 	lrm             *LocalResultManager
+	schemaRegistry  *validation.SchemaRegistry // Registry of compiled schemas
+	stop            chan struct{}              // Closed to indicate the workers must drain the queue and stop
+	workers         sync.WaitGroup             // Workers of the pool that are running
 }
 
 // GetMessageProcessorWorker returns a new message processor
@@ -54,6 +65,8 @@ func GetMessageProcessorWorker(logger log.Logger, resultProcessor *ResultProcess
 			qm:              qm,
 			cm:              cm,
 			lrm:             lrm,
+			schemaRegistry:  validation.GetSchemaRegistry(logger),
+			stop:            make(chan struct{}),
 		}
 	}
 
@@ -66,59 +79,118 @@ func GetMessageProcessorWorker(logger log.Logger, resultProcessor *ResultProcess
 //   - msg: Message to be processed
 //
 // Returns:
-func (mpw *MessageProcessorWorker) processMessage(msg *Message) {
+//   - bool: true if a result was recorded for the message
+func (mpw *MessageProcessorWorker) processMessage(msg *Message) bool {
+	// Resolved first, so the message is counted with the configured endpoint name
+	validationSettings := mpw.cm.getValidationSettings(msg)
 	messageProcessorWorkerMutex.Lock()
 	mpw.receivedValues[msg.Endpoint]++
 	messageProcessorWorkerMutex.Unlock()
 
-	validationSettings := mpw.cm.GetEndpointSettingFromAPI(msg.Endpoint, mpw.Logger)
-
 	if validationSettings == nil {
 		mpw.Logger.Warning("Ignoring message with endpoint: "+msg.Endpoint, mpw.Pack, "processMessage")
-	} else {
-		messageResult := MessageResult{
-			Endpoint:           msg.Endpoint,
-			HTTPMethod:         msg.HTTPMethod,
-			ServerID:           msg.ServerID,
-			XFapiInteractionID: msg.XFapiInteractionID,
-			TransmitterID:      msg.TransmitterID,
-		}
-		if msg.ConsentID != "" {
-			messageResult.XFapiInteractionID = "[" + msg.ConsentID + "] - [" + msg.XFapiInteractionID + "]"
-		}
+		return false
+	}
 
-		vr, err := mpw.validateMessage(msg, validationSettings.EndpointSettings)
-		if err != nil {
-			mpw.Logger.Error(err, "Error during Validation for endpoint: "+msg.Endpoint, mpw.Pack, "processMessage")
-			messageResult.Result = false
-			messageResult.Errors = map[string][]string{
-				"(error)": {err.Error()},
-			}
-		} else {
-			// Create a message result entry
-			messageResult.Result = vr.Valid
-			messageResult.Errors = vr.Errors
-		}
+	messageResult := mpw.getMessageResult(msg, validationSettings)
+	mpw.recordResult(msg, messageResult, validationSettings)
+	return true
+}
 
-		monitoring.IncreaseValidationResult(messageResult.ServerID, messageResult.Endpoint, messageResult.Result)
-		mpw.resultProcessor.AppendResult(&messageResult)
-		mpw.lrm.AppendResult(*msg, messageResult, *validationSettings)
+// ValidateMessageSync validates a message inline and returns the result to the caller, the result is only
+// added to the report counters and local results if requested
+//
+// Parameters:
+//   - msg: Message to be validated
+//   - record: Indicates if the result must be added to the report counters
+//
+// Returns:
+//   - *MessageResult: Result of the validation
+//   - error: error if the endpoint of the message is not supported
+func (mpw *MessageProcessorWorker) ValidateMessageSync(msg *Message, record bool) (*MessageResult, error) {
+	validationSettings := mpw.cm.getValidationSettings(msg)
+	if validationSettings == nil {
+		return nil, errors.New("endpoint not supported: " + msg.Endpoint)
+	}
+
+	messageResult := mpw.getMessageResult(msg, validationSettings)
+	if record {
 		messageProcessorWorkerMutex.Lock()
-		mpw.validatedValues[msg.Endpoint]++
+		mpw.receivedValues[msg.Endpoint]++
 		messageProcessorWorkerMutex.Unlock()
+		mpw.recordResult(msg, messageResult, validationSettings)
 	}
+
+	return messageResult, nil
+}
+
+// getMessageResult validates the message and creates its result
+//
+// Parameters:
+//   - msg: Message to be validated
+//   - validationSettings: API validation settings of the endpoint
+//
+// Returns:
+//   - *MessageResult: Result of the validation
+func (mpw *MessageProcessorWorker) getMessageResult(msg *Message, validationSettings *APIValidationSettings) *MessageResult {
+	messageResult := MessageResult{
+		Endpoint:             msg.Endpoint,
+		HTTPMethod:           msg.HTTPMethod,
+		ServerID:             msg.ServerID,
+		XFapiInteractionID:   msg.XFapiInteractionID,
+		TransmitterID:        msg.TransmitterID,
+		APIVersion:           validationSettings.APIVersion,
+		ConfigurationVersion: msg.ConfigurationVersion,
+	}
+	if msg.ConsentID != "" {
+		messageResult.XFapiInteractionID = "[" + msg.ConsentID + "] - [" + msg.XFapiInteractionID + "]"
+	}
+
+	vr, err := mpw.validateMessage(msg, validationSettings)
+	if err != nil {
+		mpw.Logger.Error(err, "Error during Validation for endpoint: "+msg.Endpoint, mpw.Pack, "getMessageResult")
+		messageResult.Result = false
+		messageResult.Errors = map[string][]string{
+			"(error)": {err.Error()},
+		}
+	} else {
+		// Create a message result entry
+		messageResult.Result = vr.Valid
+		messageResult.Errors = vr.Errors
+	}
+
+	return &messageResult
+}
+
+// recordResult adds the result of a message to the report counters, metrics and local results
+//
+// Parameters:
+//   - msg: Message validated
+//   - messageResult: Result of the validation
+//   - validationSettings: API validation settings of the endpoint
+//
+// Returns:
+func (mpw *MessageProcessorWorker) recordResult(msg *Message, messageResult *MessageResult, validationSettings *APIValidationSettings) {
+	monitoring.IncreaseValidationResult(messageResult.ServerID, messageResult.Endpoint, messageResult.Result)
+	mpw.resultProcessor.AppendResult(messageResult, msg)
+	mpw.lrm.AppendResult(*msg, *messageResult, *validationSettings)
+	messageProcessorWorkerMutex.Lock()
+	mpw.validatedValues[msg.Endpoint]++
+	messageProcessorWorkerMutex.Unlock()
 }
 
 // validateContentWithSchema Validates the content against a specific schema
 //
 // Parameters:
 //   - content: Content to be validated
+//   - schemaKey: Key of the schema on the schema registry
 //   - schema: JSON schema to validate with
+//   - fieldPrefix: Prefix to be added to the field names of the errors found
 //   - validationResult: Result to be filled with details from the validation
 //
 // Returns:
 //   - error: Error in case there is a problem reading or validating the schema
-func (mpw *MessageProcessorWorker) validateContentWithSchema(content string, schema string, validationResult *validation.Result) error {
+func (mpw *MessageProcessorWorker) validateContentWithSchema(content string, schemaKey string, schema string, fieldPrefix string, validationResult *validation.Result) error {
 	mpw.Logger.Info("Validating content with schema", mpw.Pack, "validateContentWithSchema")
 
 	// Create a dynamic structure from the Message content
@@ -132,6 +204,16 @@ func (mpw *MessageProcessorWorker) validateContentWithSchema(content string, sch
 	}
 
 	val := validation.GetSchemaValidator(mpw.Logger, schema)
+	if schema != "" {
+		compiled, err := mpw.schemaRegistry.GetSchema(schemaKey, schema)
+		if err != nil {
+			validationResult.Valid = false
+			return err
+		}
+
+		val = validation.GetCompiledSchemaValidator(mpw.Logger, compiled)
+	}
+
 	valRes, err := val.Validate(dynamicStruct)
 	if err != nil {
 		validationResult.Valid = false
@@ -141,7 +223,7 @@ func (mpw *MessageProcessorWorker) validateContentWithSchema(content string, sch
 
 	if !valRes.Valid {
 		for key, value := range valRes.Errors {
-			validationResult.Errors[key] = value
+			validationResult.Errors[fieldPrefix+key] = value
 		}
 
 		validationResult.Valid = valRes.Valid
@@ -154,43 +236,161 @@ func (mpw *MessageProcessorWorker) validateContentWithSchema(content string, sch
 //
 // Parameters:
 //   - msg: Message to be validated
-//   - settings: Endpoint configuration settings
+//   - validationSettings: API validation settings of the endpoint
 //
 // Returns:
 //   - ValidationResult: Result of the validation for the specified message
 //   - error: error in case there is a problem during the validation
-func (mpw *MessageProcessorWorker) validateMessage(msg *Message, settings *models.APIEndpointSetting) (*validation.Result, error) {
+func (mpw *MessageProcessorWorker) validateMessage(msg *Message, validationSettings *APIValidationSettings) (*validation.Result, error) {
 	mpw.Logger.Info("Validating message for endpoint: "+msg.Endpoint, mpw.Pack, "validateMessage")
 	validationResult := validation.Result{Valid: true, Errors: make(map[string][]string)}
 
-	err := mpw.validateContentWithSchema(msg.Message, settings.JSONBodySchema, &validationResult)
+	settings := validationSettings.EndpointSettings
+	err := mpw.validateContentWithSchema(msg.Message, validationSettings.GetSchemaKey("body"), settings.JSONBodySchema, "", &validationResult)
 	if err != nil {
 		mpw.Logger.Error(err, "Error during body validation", mpw.Pack, "validateMessage")
 		validationResult.Valid = false
 		return &validationResult, err
 	}
 
+	if msg.HeaderMessage != "" && settings.JSONHeaderSchema != "" {
+		err = mpw.validateContentWithSchema(msg.HeaderMessage, validationSettings.GetSchemaKey("header"), settings.JSONHeaderSchema, headerFieldPrefix, &validationResult)
+		if err != nil {
+			mpw.Logger.Error(err, "Error during header validation", mpw.Pack, "validateMessage")
+			validationResult.Valid = false
+			return &validationResult, err
+		}
+	}
+
+	if strings.TrimSpace(settings.HeaderValidationRules) != "" {
+		err = mpw.validateHeadersWithRules(msg, settings.HeaderValidationRules, &validationResult)
+		if err != nil {
+			mpw.Logger.Error(err, "Error during header rules validation", mpw.Pack, "validateMessage")
+			validationResult.Valid = false
+			return &validationResult, err
+		}
+	}
+
 	return &validationResult, nil
 }
 
-// worker is for starting the processing of the queued messages
+// validateHeadersWithRules Validates the headers of the message against the header validation rules of the endpoint,
+// the rules are also applied when the message has no headers, so the required headers are reported
+//
+// Parameters:
+//   - msg: Message to be validated
+//   - rules: Header validation rules of the endpoint
+//   - validationResult: Result to be filled with details from the validation
+//
+// Returns:
+//   - error: Error in case the rules or the headers can not be read
+func (mpw *MessageProcessorWorker) validateHeadersWithRules(msg *Message, rules string, validationResult *validation.Result) error {
+	headers, err := msg.GetMappedHeaders()
+	if err != nil {
+		return err
+	}
+
+	val, err := validation.GetHeaderRulesValidator(mpw.Logger, rules)
+	if err != nil {
+		return err
+	}
+
+	valRes, err := val.Validate(headers)
+	if err != nil {
+		return err
+	}
+
+	if !valRes.Valid {
+		for key, value := range valRes.Errors {
+			validationResult.Errors[headerFieldPrefix+key] = append(validationResult.Errors[headerFieldPrefix+key], value...)
+		}
+
+		validationResult.Valid = false
+	}
+
+	return nil
+}
+
+// worker is for starting the processing of the queued messages, once the worker is stopped the remaining messages
+// in the queue are processed before returning
+//
+// Parameters:
+//   - workerID: Identifier of the worker inside the pool
+//
+// Returns:
+func (mpw *MessageProcessorWorker) worker(workerID int) {
+	defer mpw.workers.Done()
+	for {
+		select {
+		case msg := <-mpw.qm.GetQueue():
+			mpw.processQueuedMessage(workerID, msg)
+		case <-mpw.stop:
+			for {
+				select {
+				case msg := <-mpw.qm.GetQueue():
+					mpw.processQueuedMessage(workerID, msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// processQueuedMessage processes a message taken from the queue, messages with a result are acknowledged by the
+// result processor once the report is sent, ignored messages are acknowledged right away
 //
 // Parameters:
+//   - workerID: Identifier of the worker processing the message
+//   - msg: Message to be processed
 //
 // Returns:
-func (mpw *MessageProcessorWorker) worker() {
-	for msg := range mpw.qm.GetQueue() {
-		mpw.processMessage(msg)
+func (mpw *MessageProcessorWorker) processQueuedMessage(workerID int, msg *Message) {
+	startTime := time.Now()
+	if !mpw.processMessage(msg) {
+		mpw.qm.Acknowledge(msg)
 	}
+
+	monitoring.RecordWorkerProcessedMessage(workerID, startTime)
 }
 
-// StartWorker is for starting the worker process
+// StartWorker is for starting the worker pool, each worker of the pool consumes the queue concurrently
 //
 // Parameters:
 //
 // Returns:
 func (mpw *MessageProcessorWorker) StartWorker() {
-	go mpw.worker() // Start the worker Goroutine to process messages
+	poolSize := mpw.cm.GetWorkerPoolSize()
+	for i := 0; i < poolSize; i++ {
+		mpw.workers.Add(1)
+		go mpw.worker(i) // Start the worker Goroutine to process messages
+	}
 
-	mpw.Logger.Log("Worker started.", mpw.Pack, "StartWorker")
+	mpw.Logger.Log("Worker pool started with "+strconv.Itoa(poolSize)+" workers.", mpw.Pack, "StartWorker")
+}
+
+// Stop indicates the workers to process the remaining messages in the queue and waits until they finish
+//
+// Parameters:
+//   - ctx: Context with the deadline for draining the queue
+//
+// Returns:
+//   - error: error if the queue was not drained before the deadline
+func (mpw *MessageProcessorWorker) Stop(ctx context.Context) error {
+	mpw.Logger.Info("Draining message queue, pending messages: "+strconv.Itoa(len(mpw.qm.GetQueue())), mpw.Pack, "Stop")
+	close(mpw.stop)
+
+	done := make(chan struct{})
+	go func() {
+		mpw.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		mpw.Logger.Info("Message queue drained.", mpw.Pack, "Stop")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
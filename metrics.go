@@ -41,15 +41,22 @@ type SystemMetrics struct {
 	RequestsReceived    string
 	BadRequestsReceived string
 	AverageResponseTime string
+	DroppedMessages     string
 }
 
 var (
-	requests                 metric.Float64Counter // Stores the number of requests the application has received
-	endpointRequests         metric.Float64Counter // Stores the number of requests by endpoint / server
-	endpointValidationErrors metric.Float64Counter // Stores the number of validation errors by endpoint / server
-	mutex                    = sync.Mutex{}        // Mutex for thread-safe access
-	requestsReceived         = 0                   // Stores the number of requests received
-	badRequestsReceived      = 0                   // Stores the number of bad requests errors
+	requests                 metric.Float64Counter   // Stores the number of requests the application has received
+	endpointRequests         metric.Float64Counter   // Stores the number of requests by endpoint / server
+	endpointValidationErrors metric.Float64Counter   // Stores the number of validation errors by endpoint / server
+	workerMessages           metric.Float64Counter   // Stores the number of messages processed by worker
+	workerProcessingTime     metric.Float64Histogram // Stores the processing time of the messages by worker
+	droppedMessagesCounter   metric.Float64Counter   // Stores the number of messages dropped because the queue was full
+	rejectedAuthentications  metric.Float64Counter   // Stores the number of requests rejected by the inbound authentication
+	mutex                    = sync.Mutex{}          // Mutex for thread-safe access
+	requestsReceived         = 0                     // Stores the number of requests received
+	badRequestsReceived      = 0                     // Stores the number of bad requests errors
+	droppedMessages          = 0                     // Stores the number of messages dropped in the report window
+	reportOutboxDepth        int64                   // Stores the number of reports waiting to be sent again
 	measurements             []Measurement
 	responseTime             []time.Duration
 	unsupportedEndpoints     = make(map[string]map[string]int) // Stores the number of unsupported endpoints
@@ -191,6 +198,57 @@ func StartOpenTelemetry() {
 		log.Fatal(err)
 	}
 
+	workerMessages, err = meter.Float64Counter(
+		"worker_processed_messages",
+		metric.WithDescription("Messages processed by worker"),
+		metric.WithUnit("messages"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	workerProcessingTime, err = meter.Float64Histogram(
+		"worker_processing_time",
+		metric.WithDescription("Message processing time by worker"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	droppedMessagesCounter, err = meter.Float64Counter(
+		"dropped_messages",
+		metric.WithDescription("Messages dropped because the queue was full"),
+		metric.WithUnit("messages"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = meter.Int64ObservableGauge(
+		"report_outbox_depth",
+		metric.WithDescription("Reports waiting in the outbox to be sent again"),
+		metric.WithUnit("reports"),
+		metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+			mutex.Lock()
+			observer.Observe(reportOutboxDepth)
+			mutex.Unlock()
+			return nil
+		}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rejectedAuthentications, err = meter.Float64Counter(
+		"rejected_authentications",
+		metric.WithDescription("Requests rejected by the inbound authentication by route"),
+		metric.WithUnit("requests"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	requests.Add(ctx, 0)
 }
 
@@ -235,6 +293,40 @@ func IncreaseBadRequestsReceived() {
 	mutex.Unlock()
 }
 
+// IncreaseDroppedMessages increases the number of messages dropped because the queue was full
+//
+// Parameters:
+//
+// Returns:
+func IncreaseDroppedMessages() {
+	mutex.Lock()
+	droppedMessages++
+	droppedMessagesCounter.Add(context.Background(), 1)
+	mutex.Unlock()
+}
+
+// SetReportOutboxDepth sets the number of reports waiting in the outbox to be sent again
+//
+// Parameters:
+//   - depth: Number of reports in the outbox
+//
+// Returns:
+func SetReportOutboxDepth(depth int) {
+	mutex.Lock()
+	reportOutboxDepth = int64(depth)
+	mutex.Unlock()
+}
+
+// IncreaseRejectedAuthentications increases the number of requests rejected by the inbound authentication
+//
+// Parameters:
+//   - route: Name of the route requested
+//
+// Returns:
+func IncreaseRejectedAuthentications(route string) {
+	rejectedAuthentications.Add(context.Background(), 1, metric.WithAttributes(attribute.Key("route").String(route)))
+}
+
 // IncreaseBadEndpointsReceived increases the number of bad requests received metric
 //
 // Parameters:
@@ -274,6 +366,19 @@ func IncreaseValidationResult(serverID string, endpointName string, valid bool)
 	mutex.Unlock()
 }
 
+// RecordWorkerProcessedMessage records a message processed by a specific worker of the pool
+//
+// Parameters:
+//   - workerID: Identifier of the worker
+//   - startTime: Time when the worker started processing the message
+//
+// Returns:
+func RecordWorkerProcessedMessage(workerID int, startTime time.Time) {
+	attributes := metric.WithAttributes(attribute.Key("worker").Int(workerID))
+	workerMessages.Add(context.Background(), 1, attributes)
+	workerProcessingTime.Record(context.Background(), float64(time.Since(startTime).Microseconds())/1000, attributes)
+}
+
 // GetAndCleanRequestsReceived returns and cleans the lists of requests
 // @author AB
 // @params
@@ -362,8 +467,11 @@ func GetAndCleanSystemMetrics() SystemMetrics {
 		RequestsReceived:    strconv.Itoa(getAndCleanRequestsReceived()),
 		BadRequestsReceived: strconv.Itoa(getAndCleanBadRequestsReceived()),
 		AverageResponseTime: getAndCleanResponseTime(),
+		DroppedMessages:     strconv.Itoa(droppedMessages),
 	}
 
+	droppedMessages = 0
+
 	// Reset measurements for the next interval
 	measurements = []Measurement{}
 	mutex.Unlock()
@@ -0,0 +1,485 @@
+package application
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+const (
+	segmentExtension    = ".log"          // Extension of the files with the queued messages
+	ackExtension        = ".ack"          // Extension of the files with the processed message ids
+	fsyncIntervalPeriod = 1 * time.Second // Time between fsync calls for the INTERVAL policy
+)
+
+// persistentRecord is the entry written to a segment for each queued message
+type persistentRecord struct {
+	ID      uint64   `json:"id"`      // Identifier of the message in the queue
+	Message *Message `json:"message"` // Message queued
+}
+
+// queueSegment stores the information of a segment file of the queue
+type queueSegment struct {
+	id      uint64   // Identifier of the segment
+	file    *os.File // Segment file, only open for the active segment
+	ackFile *os.File // File with the processed message ids of the segment
+	records int      // Number of messages written to the segment
+	acked   int      // Number of messages of the segment already processed
+	sealed  bool     // Indicates that no more messages will be written to the segment
+}
+
+// PersistentQueue is an append-only segment log that keeps the queued messages on disk until they are processed
+type PersistentQueue struct {
+	crosscutting.OFBStruct
+	directory   string                   // Directory where the segments are stored
+	fsyncPolicy string                   // Policy for flushing the segments to disk
+	segmentSize int                      // Number of messages per segment
+	mutex       sync.Mutex               // Mutex for thread-safe access to the segments
+	nextID      uint64                   // Identifier for the next message
+	active      *queueSegment            // Segment receiving new messages
+	pending     map[uint64]*queueSegment // Segments of the messages not processed yet, by message id
+	unsynced    map[uint64]*queueSegment // Segments written since the last flush, by segment id
+	stop        chan struct{}            // Closed to stop the periodic flush
+}
+
+// NewPersistentQueue creates a new persistent queue
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - directory: Directory where the segments are stored
+//   - fsyncPolicy: Policy for flushing the segments to disk (ALWAYS, INTERVAL, NEVER)
+//   - segmentSize: Number of messages per segment
+//
+// Returns:
+//   - *PersistentQueue: New persistent queue
+//   - error: error if the directory can not be created
+func NewPersistentQueue(logger log.Logger, directory string, fsyncPolicy string, segmentSize int) (*PersistentQueue, error) {
+	if err := os.MkdirAll(directory, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create queue folder %s: %w", directory, err)
+	}
+
+	pq := &PersistentQueue{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.PersistentQueue",
+			Logger: logger,
+		},
+		directory:   directory,
+		fsyncPolicy: fsyncPolicy,
+		segmentSize: segmentSize,
+		nextID:      1,
+		pending:     make(map[uint64]*queueSegment),
+		unsynced:    make(map[uint64]*queueSegment),
+		stop:        make(chan struct{}),
+	}
+
+	if fsyncPolicy == configuration.FsyncInterval {
+		go pq.startSyncProcess()
+	}
+
+	return pq, nil
+}
+
+// startSyncProcess flushes to disk the segments written since the last flush, once per interval until the queue
+// is closed
+//
+// Parameters:
+//
+// Returns:
+func (pq *PersistentQueue) startSyncProcess() {
+	ticker := time.NewTicker(fsyncIntervalPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			pq.mutex.Lock()
+			pq.syncSegments()
+			pq.mutex.Unlock()
+		case <-pq.stop:
+			return
+		}
+	}
+}
+
+// syncSegments flushes the open files of the segments written since the last flush, the mutex must be held by the caller
+//
+// Parameters:
+//
+// Returns:
+func (pq *PersistentQueue) syncSegments() {
+	for id, segment := range pq.unsynced {
+		for _, file := range []*os.File{segment.file, segment.ackFile} {
+			if file == nil {
+				continue
+			}
+
+			if err := file.Sync(); err != nil {
+				pq.Logger.Error(err, "error flushing queue file", pq.Pack, "syncSegments")
+			}
+		}
+
+		delete(pq.unsynced, id)
+	}
+}
+
+// Recover loads the messages that were not processed before the last shutdown, segments without pending messages are removed
+//
+// Parameters:
+//
+// Returns:
+//   - []*Message: Messages not processed yet, in the order they were queued
+//   - error: error if any
+func (pq *PersistentQueue) Recover() ([]*Message, error) {
+	pq.mutex.Lock()
+	defer pq.mutex.Unlock()
+
+	segmentIDs, err := pq.listSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Message, 0)
+	lastSegmentID := uint64(0)
+	for _, segmentID := range segmentIDs {
+		lastSegmentID = segmentID
+		records, err := pq.readSegment(segmentID)
+		if err != nil {
+			return nil, err
+		}
+
+		acked, err := pq.readAcks(segmentID)
+		if err != nil {
+			return nil, err
+		}
+
+		segment := &queueSegment{id: segmentID, records: len(records), sealed: true}
+		for _, record := range records {
+			if record.ID >= pq.nextID {
+				pq.nextID = record.ID + 1
+			}
+
+			if acked[record.ID] {
+				segment.acked++
+				continue
+			}
+
+			record.Message.queueID = record.ID
+			pq.pending[record.ID] = segment
+			result = append(result, record.Message)
+		}
+
+		if segment.acked >= segment.records {
+			pq.removeSegment(segment)
+		}
+	}
+
+	pq.Logger.Info("Messages recovered from disk: "+strconv.Itoa(len(result)), pq.Pack, "Recover")
+	return result, pq.openSegment(lastSegmentID + 1)
+}
+
+// Append writes a message to the active segment and assigns its queue identifier
+//
+// Parameters:
+//   - msg: Message to be written
+//
+// Returns:
+//   - error: error if any
+func (pq *PersistentQueue) Append(msg *Message) error {
+	pq.mutex.Lock()
+	defer pq.mutex.Unlock()
+
+	if pq.active == nil {
+		if err := pq.openSegment(1); err != nil {
+			return err
+		}
+	}
+
+	record := persistentRecord{ID: pq.nextID, Message: msg}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if _, err = pq.active.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	switch pq.fsyncPolicy {
+	case configuration.FsyncAlways:
+		if err = pq.active.file.Sync(); err != nil {
+			pq.Logger.Error(err, "error flushing queue segment", pq.Pack, "Append")
+		}
+	case configuration.FsyncInterval:
+		pq.unsynced[pq.active.id] = pq.active
+	}
+
+	msg.queueID = record.ID
+	pq.nextID++
+	pq.active.records++
+	pq.pending[record.ID] = pq.active
+	if pq.active.records >= pq.segmentSize {
+		return pq.openSegment(pq.active.id + 1)
+	}
+
+	return nil
+}
+
+// Acknowledge marks a message as processed, the segment is removed once all its messages are processed
+//
+// Parameters:
+//   - msg: Message processed
+//
+// Returns:
+func (pq *PersistentQueue) Acknowledge(msg *Message) {
+	pq.mutex.Lock()
+	defer pq.mutex.Unlock()
+
+	segment, ok := pq.pending[msg.queueID]
+	if !ok {
+		return
+	}
+
+	delete(pq.pending, msg.queueID)
+	if segment.ackFile == nil {
+		ackFile, err := os.OpenFile(pq.getFilePath(segment.id, ackExtension), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			pq.Logger.Error(err, "error opening acknowledge file", pq.Pack, "Acknowledge")
+			return
+		}
+
+		segment.ackFile = ackFile
+	}
+
+	if _, err := segment.ackFile.WriteString(strconv.FormatUint(msg.queueID, 10) + "\n"); err != nil {
+		pq.Logger.Error(err, "error writing acknowledge file", pq.Pack, "Acknowledge")
+	}
+
+	switch pq.fsyncPolicy {
+	case configuration.FsyncAlways:
+		if err := segment.ackFile.Sync(); err != nil {
+			pq.Logger.Error(err, "error flushing acknowledge file", pq.Pack, "Acknowledge")
+		}
+	case configuration.FsyncInterval:
+		pq.unsynced[segment.id] = segment
+	}
+
+	segment.acked++
+	if segment.sealed && segment.acked >= segment.records {
+		pq.removeSegment(segment)
+	}
+}
+
+// Close stops the periodic flush, then flushes and closes the open segment files
+//
+// Parameters:
+//
+// Returns:
+func (pq *PersistentQueue) Close() {
+	close(pq.stop)
+	pq.mutex.Lock()
+	defer pq.mutex.Unlock()
+
+	closed := make(map[uint64]bool)
+	for _, segment := range pq.pending {
+		if closed[segment.id] {
+			continue
+		}
+
+		closed[segment.id] = true
+		pq.closeSegmentFiles(segment)
+	}
+
+	if pq.active != nil && !closed[pq.active.id] {
+		pq.closeSegmentFiles(pq.active)
+	}
+}
+
+// openSegment seals the active segment and opens a new one
+//
+// Parameters:
+//   - segmentID: Identifier of the new segment
+//
+// Returns:
+//   - error: error if any
+func (pq *PersistentQueue) openSegment(segmentID uint64) error {
+	if pq.active != nil {
+		pq.active.sealed = true
+		if err := pq.active.file.Sync(); err != nil {
+			pq.Logger.Error(err, "error flushing queue segment", pq.Pack, "openSegment")
+		}
+
+		if err := pq.active.file.Close(); err != nil {
+			pq.Logger.Error(err, "error closing queue segment", pq.Pack, "openSegment")
+		}
+
+		pq.active.file = nil
+		if pq.active.acked >= pq.active.records {
+			pq.removeSegment(pq.active)
+		}
+	}
+
+	file, err := os.OpenFile(pq.getFilePath(segmentID, segmentExtension), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create queue segment: %w", err)
+	}
+
+	pq.active = &queueSegment{id: segmentID, file: file}
+	return nil
+}
+
+// removeSegment deletes the files of a segment that has no pending messages
+//
+// Parameters:
+//   - segment: Segment to be removed
+//
+// Returns:
+func (pq *PersistentQueue) removeSegment(segment *queueSegment) {
+	delete(pq.unsynced, segment.id)
+	pq.closeSegmentFiles(segment)
+	for _, extension := range []string{segmentExtension, ackExtension} {
+		err := os.Remove(pq.getFilePath(segment.id, extension))
+		if err != nil && !os.IsNotExist(err) {
+			pq.Logger.Error(err, "error removing queue segment", pq.Pack, "removeSegment")
+		}
+	}
+}
+
+// closeSegmentFiles closes the open files of a segment
+//
+// Parameters:
+//   - segment: Segment to be closed
+//
+// Returns:
+func (pq *PersistentQueue) closeSegmentFiles(segment *queueSegment) {
+	for _, file := range []*os.File{segment.file, segment.ackFile} {
+		if file == nil {
+			continue
+		}
+
+		if err := file.Sync(); err != nil {
+			pq.Logger.Error(err, "error flushing queue file", pq.Pack, "closeSegmentFiles")
+		}
+
+		if err := file.Close(); err != nil {
+			pq.Logger.Error(err, "error closing queue file", pq.Pack, "closeSegmentFiles")
+		}
+	}
+
+	segment.file = nil
+	segment.ackFile = nil
+}
+
+// listSegments returns the identifiers of the segments found in the directory
+//
+// Parameters:
+//
+// Returns:
+//   - []uint64: Segment identifiers in ascending order
+//   - error: error if any
+func (pq *PersistentQueue) listSegments() ([]uint64, error) {
+	entries, err := os.ReadDir(pq.directory)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]uint64, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), segmentExtension) {
+			continue
+		}
+
+		id, err := strconv.ParseUint(strings.TrimSuffix(entry.Name(), segmentExtension), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		result = append(result, id)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result, nil
+}
+
+// readSegment reads the messages written to a segment, incomplete entries are skipped
+//
+// Parameters:
+//   - segmentID: Identifier of the segment
+//
+// Returns:
+//   - []persistentRecord: Records found in the segment
+//   - error: error if any
+func (pq *PersistentQueue) readSegment(segmentID uint64) ([]persistentRecord, error) {
+	file, err := os.Open(pq.getFilePath(segmentID, segmentExtension))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := make([]persistentRecord, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var record persistentRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Message == nil {
+			// An incomplete entry can be found if the application stopped while writing
+			pq.Logger.Warning("Skipping corrupted entry in segment: "+strconv.FormatUint(segmentID, 10), pq.Pack, "readSegment")
+			continue
+		}
+
+		result = append(result, record)
+	}
+
+	return result, scanner.Err()
+}
+
+// readAcks reads the processed message ids of a segment
+//
+// Parameters:
+//   - segmentID: Identifier of the segment
+//
+// Returns:
+//   - map[uint64]bool: Identifiers of the processed messages
+//   - error: error if any
+func (pq *PersistentQueue) readAcks(segmentID uint64) (map[uint64]bool, error) {
+	result := make(map[uint64]bool)
+	file, err := os.Open(pq.getFilePath(segmentID, ackExtension))
+	if os.IsNotExist(err) {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		id, err := strconv.ParseUint(strings.TrimSpace(scanner.Text()), 10, 64)
+		if err == nil {
+			result[id] = true
+		}
+	}
+
+	return result, scanner.Err()
+}
+
+// getFilePath returns the path of a segment file
+//
+// Parameters:
+//   - segmentID: Identifier of the segment
+//   - extension: Extension of the file
+//
+// Returns:
+//   - string: path of the file
+func (pq *PersistentQueue) getFilePath(segmentID uint64, extension string) string {
+	return filepath.Join(pq.directory, fmt.Sprintf("%020d%s", segmentID, extension))
+}
@@ -1,22 +1,35 @@
 package application
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"sync"
 
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
 	"github.com/OpenBanking-Brasil/MQD_Client/validation"
 )
 
 // Message contains the information of the Payload to be validated
 type Message struct {
-	Message string `json:"message"` // Body Payload sent to the API
-	//HeaderMessage      string `json:"header_message"` // Header Payload sent to the API
-	Endpoint           string `json:"endpoint"`    // Name of the endpoint requested
-	APIVersion         string `json:"api_version"` // Version of the API to validate
-	HTTPMethod         string `json:"http_method"` // HTTP Method used
-	ServerID           string `json:"server_id"`   // Identifier of the Client requesting the information
-	XFapiInteractionID string
-	ConsentID          string
-	TransmitterID      string // Organisation ID of the transmitter
+	Message              string `json:"message"`        // Body Payload sent to the API
+	HeaderMessage        string `json:"header_message"` // Header Payload sent to the API
+	Endpoint             string `json:"endpoint"`       // Name of the endpoint requested
+	APIVersion           string `json:"api_version"`    // Version of the API to validate
+	HTTPMethod           string `json:"http_method"`    // HTTP Method used
+	ServerID             string `json:"server_id"`      // Identifier of the Client requesting the information
+	XFapiInteractionID   string
+	ConsentID            string
+	TransmitterID        string                 // Organisation ID of the transmitter
+	RequestPath          string                 `json:"request_path"`          // Path of the original request, used when the endpoint name is not informed
+	RequestMethod        string                 `json:"request_method"`        // HTTP method of the original request, empty if not informed
+	ConfigurationVersion string                 `json:"configuration_version"` // Configuration version captured at ingestion
+	queueID              uint64                 // Identifier of the message in the persistent queue, 0 if not persisted
+	onAcknowledge        func()                 // Called once the message is processed or discarded, nil if not needed by the source
+	snapshot             *ConfigurationSnapshot // Configuration captured at ingestion, nil for messages restored from the persistent queue
+	validationSettings   *APIValidationSettings // Validation settings of the endpoint resolved with the snapshot
 }
 
 // GetMappedObject Returns the json message object mapped as a dynamic structure
@@ -37,31 +50,219 @@ func (msg *Message) GetMappedObject() (validation.DynamicStruct, error) {
 	return dynamicStruct, nil
 }
 
-// Buffered channel for message queue
-var messageQueue = make(chan *Message, 1000)
+// GetMappedHeaders Returns the json header object mapped as a dynamic structure
+//
+// Parameters:
+//
+// Returns:
+//   - DynamicStruct: mapped object, nil if the message has no headers
+//   - Error: Error if any during the unmarshal process
+func (msg *Message) GetMappedHeaders() (validation.DynamicStruct, error) {
+	if msg.HeaderMessage == "" {
+		return nil, nil
+	}
+
+	var dynamicStruct validation.DynamicStruct
+	err := json.Unmarshal([]byte(msg.HeaderMessage), &dynamicStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamicStruct, nil
+}
+
+var (
+	// ErrQueueFull is returned when a message could not be queued because the queue is full
+	ErrQueueFull = errors.New("message queue is full")
+
+	queueManagerSingleton *QueueManager  // Singleton for the queue manager
+	queueManagerMutex     = sync.Mutex{} // Mutex for the singleton variable
+)
 
 // QueueManager is in charge of managing the queue for messages to process
 type QueueManager struct {
+	crosscutting.OFBStruct
+	messageQueue chan *Message    // Buffered channel for message queue
+	policy       string           // Policy to apply when the queue is full
+	store        *PersistentQueue // Persistent storage for the queued messages, nil if disabled
+}
+
+// GetQueueManager returns the queue manager
+//
+// Parameters:
+//   - cm: Configuration manager with the queue settings
+//
+// Returns:
+//   - *QueueManager: Queue manager
+func GetQueueManager(cm *ConfigurationManager) *QueueManager {
+	queueManagerMutex.Lock()
+	defer queueManagerMutex.Unlock()
+	if queueManagerSingleton == nil {
+		queueManagerSingleton = &QueueManager{
+			OFBStruct: crosscutting.OFBStruct{
+				Pack:   "application.QueueManager",
+				Logger: cm.Logger,
+			},
+			messageQueue: make(chan *Message, cm.GetQueueCapacity()),
+			policy:       cm.GetQueueFullPolicy(),
+		}
+
+		if cm.settings.PersistentQueueSettings.Enabled {
+			queueManagerSingleton.startPersistentQueue(cm)
+		}
+	}
+
+	return queueManagerSingleton
+}
+
+// startPersistentQueue opens the persistent queue and queues again the messages not processed before the last shutdown
+//
+// Parameters:
+//   - cm: Configuration manager with the queue settings
+//
+// Returns:
+func (qm *QueueManager) startPersistentQueue(cm *ConfigurationManager) {
+	settings := cm.settings.PersistentQueueSettings
+	store, err := NewPersistentQueue(qm.Logger, settings.Directory, settings.FsyncPolicy, settings.SegmentSize)
+	if err != nil {
+		qm.Logger.Error(err, "Error opening persistent queue, using memory queue", qm.Pack, "startPersistentQueue")
+		return
+	}
+
+	pending, err := store.Recover()
+	if err != nil {
+		qm.Logger.Error(err, "Error recovering persistent queue, using memory queue", qm.Pack, "startPersistentQueue")
+		store.Close()
+		return
+	}
+
+	qm.store = store
+	// Recovered messages may exceed the queue capacity, they are queued as the workers consume the queue
+	go func() {
+		for _, msg := range pending {
+			qm.messageQueue <- msg
+		}
+	}()
 }
 
-// GetQueueManager returns a new queue manager
+// EnqueueMessage is for queueing the message without blocking, when the queue is full the configured policy is applied
 //
 // Parameters:
+//   - msg: Message to be queued
 //
 // Returns:
-//   - *QueueManager: New queue manager
-func GetQueueManager() *QueueManager {
-	return &QueueManager{}
+//   - error: ErrQueueFull if the message was not queued
+func (qm *QueueManager) EnqueueMessage(msg *Message) error {
+	qm.storeMessage(msg, "EnqueueMessage")
+
+	select {
+	case qm.messageQueue <- msg:
+		return nil
+	default:
+	}
+
+	if qm.policy == configuration.QueueDropOldest {
+		// Discard the oldest message to make room for the new one
+		select {
+		case oldest := <-qm.messageQueue:
+			qm.Acknowledge(oldest)
+			monitoring.IncreaseDroppedMessages()
+		default:
+		}
+
+		select {
+		case qm.messageQueue <- msg:
+			return nil
+		default:
+		}
+	}
+
+	qm.Acknowledge(msg)
+	monitoring.IncreaseDroppedMessages()
+	return ErrQueueFull
 }
 
-// EnqueueMessage is for queueing the message
+// EnqueueMessageWait is for queueing the message waiting until there is room in the queue, it is used by the
+// ingestion sources that can apply backpressure instead of discarding messages
 //
 // Parameters:
+//   - ctx: Context to stop waiting
 //   - msg: Message to be queued
 //
 // Returns:
-func (qm *QueueManager) EnqueueMessage(msg *Message) {
-	messageQueue <- msg
+//   - error: context error if the message was not queued
+func (qm *QueueManager) EnqueueMessageWait(ctx context.Context, msg *Message) error {
+	qm.storeMessage(msg, "EnqueueMessageWait")
+
+	select {
+	case qm.messageQueue <- msg:
+		return nil
+	case <-ctx.Done():
+		// Removed from the persistent queue as the source will deliver it again
+		if qm.store != nil && msg.queueID != 0 {
+			qm.store.Acknowledge(msg)
+		}
+
+		return ctx.Err()
+	}
+}
+
+// storeMessage writes the message to the persistent queue if enabled. Messages from ingestion sources (with
+// onAcknowledge) are not written, the source keeps them until they are acknowledged and delivers them again after a
+// restart, so recovering them from the persistent queue would process them twice
+//
+// Parameters:
+//   - msg: Message to be stored
+//   - function: Name of the function storing the message, used for logging
+//
+// Returns:
+func (qm *QueueManager) storeMessage(msg *Message, function string) {
+	if qm.store == nil || msg.onAcknowledge != nil {
+		return
+	}
+
+	err := qm.store.Append(msg)
+	if err != nil {
+		qm.Logger.Error(err, "Error writing message to persistent queue", qm.Pack, function)
+	}
+}
+
+// Acknowledge indicates that a message was processed (or discarded) and can be removed from the persistent queue,
+// the source of the message is notified if needed
+//
+// Parameters:
+//   - msg: Message processed
+//
+// Returns:
+func (qm *QueueManager) Acknowledge(msg *Message) {
+	if qm.store != nil && msg.queueID != 0 {
+		qm.store.Acknowledge(msg)
+	}
+
+	if msg.onAcknowledge != nil {
+		msg.onAcknowledge()
+	}
+}
+
+// IsRejectPolicy indicates if requests must be rejected when the queue is full
+//
+// Parameters:
+//
+// Returns:
+//   - bool: true if the REJECT policy is configured
+func (qm *QueueManager) IsRejectPolicy() bool {
+	return qm.policy == configuration.QueueReject
+}
+
+// Close flushes and closes the persistent queue if enabled
+//
+// Parameters:
+//
+// Returns:
+func (qm *QueueManager) Close() {
+	if qm.store != nil {
+		qm.store.Close()
+	}
 }
 
 // GetQueue returns the list of messages in the queue
@@ -71,5 +272,5 @@ func (qm *QueueManager) EnqueueMessage(msg *Message) {
 // Returns:
 //   - chan *Message: List of messages in the queue
 func (qm *QueueManager) GetQueue() chan *Message {
-	return messageQueue
+	return qm.messageQueue
 }
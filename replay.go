@@ -0,0 +1,366 @@
+package application
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+)
+
+const (
+	replayReportFile   = "report.json"    // Name of the file with the summary of the replay
+	maxReplayLineBytes = 10 * 1024 * 1024 // Max size of a NDJSON line
+)
+
+// harFile contains the parts of a HAR file used by the replay
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+// harEntry contains a request / response pair of a HAR file
+type harEntry struct {
+	Request struct {
+		Method  string      `json:"method"`
+		URL     string      `json:"url"`
+		Headers []harHeader `json:"headers"`
+	} `json:"request"`
+	Response struct {
+		Headers []harHeader `json:"headers"`
+		Content struct {
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// harHeader contains a header of a HAR request or response
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Replayer validates captured responses offline, without sending anything to the central server
+type Replayer struct {
+	crosscutting.OFBStruct
+	cm          *ConfigurationManager                  // Manager for application settings
+	mpw         *MessageProcessorWorker                // Message processor used for the validation
+	rp          *ResultProcessor                       // Result processor used to create the summary
+	lrm         *LocalResultManager                    // Local result manager storing the error samples
+	directory   string                                 // Directory where the results are stored
+	serverID    string                                 // Server ID used when the messages do not include one
+	results     map[string]TransmitterResults          // Results by transmitter
+	unsupported map[string]*models.UnsupportedEndpoint // Unsupported endpoints by name and version
+	skipped     int                                    // Number of messages that could not be read
+}
+
+// NewReplayer creates a new Replayer
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - cm: Configuration manager with the validation settings loaded
+//   - directory: Directory where the results are stored
+//   - serverID: Server ID used when the messages do not include one
+//
+// Returns:
+//   - *Replayer: Replayer created
+func NewReplayer(logger log.Logger, cm *ConfigurationManager, directory string, serverID string) *Replayer {
+	lrm := NewLocalResultManager(logger, cm)
+	lrm.directory = directory
+	lrm.alwaysEnabled = true
+
+	return &Replayer{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.Replayer",
+			Logger: logger,
+		},
+		cm: cm,
+		mpw: &MessageProcessorWorker{
+			OFBStruct: crosscutting.OFBStruct{
+				Pack:   "replay",
+				Logger: logger,
+			},
+			cm:             cm,
+			schemaRegistry: validation.GetSchemaRegistry(logger),
+		},
+		rp: &ResultProcessor{
+			OFBStruct: crosscutting.OFBStruct{
+				Pack:   "replay",
+				Logger: logger,
+			},
+			cm: cm,
+		},
+		lrm:         lrm,
+		directory:   directory,
+		serverID:    serverID,
+		results:     make(map[string]TransmitterResults),
+		unsupported: make(map[string]*models.UnsupportedEndpoint),
+	}
+}
+
+// ReplayFile validates the messages of a HAR file (.har) or a NDJSON file, with one message per line in the same
+// format as the /ValidateResponses items
+//
+// Parameters:
+//   - path: Path of the file
+//
+// Returns:
+//   - error: error if the file could not be read
+func (rpl *Replayer) ReplayFile(path string) error {
+	rpl.Logger.Info("Replaying file: "+path, rpl.Pack, "ReplayFile")
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".har") {
+		var har harFile
+		err = json.NewDecoder(file).Decode(&har)
+		if err != nil {
+			return fmt.Errorf("invalid HAR file %s: %w", path, err)
+		}
+
+		for _, entry := range har.Log.Entries {
+			item, err := rpl.getHARItem(entry)
+			if err != nil {
+				rpl.skipped++
+				rpl.Logger.Warning("Entry skipped, "+entry.Request.URL+": "+err.Error(), rpl.Pack, "ReplayFile")
+				continue
+			}
+
+			rpl.replayItem(item, entry.Request.Method)
+		}
+
+		return nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReplayLineBytes)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var item BatchItem
+		err = json.Unmarshal(scanner.Bytes(), &item)
+		if err != nil {
+			rpl.skipped++
+			rpl.Logger.Warning(fmt.Sprintf("Line %d skipped: %s", line, err.Error()), rpl.Pack, "ReplayFile")
+			continue
+		}
+
+		rpl.replayItem(&item, "")
+	}
+
+	return scanner.Err()
+}
+
+// getHARItem maps a HAR entry to a message, the endpoint name and version are read from the request headers
+// if the capture was made after the gateway, otherwise the endpoint is resolved with the URL path and method
+//
+// Parameters:
+//   - entry: HAR entry
+//
+// Returns:
+//   - *BatchItem: Message with the values of the entry
+//   - error: error if the entry could not be mapped
+func (rpl *Replayer) getHARItem(entry harEntry) (*BatchItem, error) {
+	item := &BatchItem{ResponseHeaders: make(map[string]string)}
+	for _, header := range entry.Request.Headers {
+		switch strings.ToLower(header.Name) {
+		case strings.ToLower(srvOrgID):
+			item.ServerOrgID = header.Value
+		case "endpointname":
+			item.EndpointName = header.Value
+		case "version":
+			item.Version = header.Value
+		case strings.ToLower(transmitterID):
+			item.TransmitterID = header.Value
+		}
+	}
+
+	for _, header := range entry.Response.Headers {
+		item.ResponseHeaders[strings.ToLower(header.Name)] = header.Value
+	}
+
+	item.XFapiInteractionID = item.ResponseHeaders[xFAPIInteractionID]
+	if item.EndpointName == "" {
+		requestURL, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		item.RequestPath = requestURL.Path
+		item.HTTPMethod = entry.Request.Method
+	}
+
+	body := entry.Response.Content.Text
+	if entry.Response.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, err
+		}
+
+		body = string(decoded)
+	}
+
+	if body == "" {
+		return nil, errors.New("response without body")
+	}
+
+	item.Body = json.RawMessage(body)
+	return item, nil
+}
+
+// replayItem validates a message and adds its result to the summary
+//
+// Parameters:
+//   - item: Message to be validated
+//   - httpMethod: HTTP method of the captured request
+//
+// Returns:
+func (rpl *Replayer) replayItem(item *BatchItem, httpMethod string) {
+	msg := Message{
+		Message:            string(item.Body),
+		Endpoint:           item.EndpointName,
+		APIVersion:         item.Version,
+		HTTPMethod:         httpMethod,
+		ServerID:           item.ServerOrgID,
+		XFapiInteractionID: item.XFapiInteractionID,
+		ConsentID:          item.ConsentID,
+		TransmitterID:      item.TransmitterID,
+		RequestPath:        item.RequestPath,
+		RequestMethod:      strings.ToUpper(item.HTTPMethod),
+	}
+
+	if msg.ServerID == "" {
+		msg.ServerID = rpl.serverID
+	}
+
+	if msg.RequestMethod != "" {
+		msg.HTTPMethod = msg.RequestMethod
+	}
+
+	if len(item.ResponseHeaders) > 0 {
+		headers := make(map[string]string)
+		for key, value := range item.ResponseHeaders {
+			headers[strings.ToLower(key)] = value
+		}
+
+		headerMessage, _ := json.Marshal(headers)
+		msg.HeaderMessage = string(headerMessage)
+	}
+
+	validationSettings := rpl.cm.getValidationSettings(&msg)
+	if validationSettings == nil {
+		if anyVersion := rpl.cm.getEndpointAnyVersion(&msg); anyVersion != nil {
+			rpl.addUnsupported(anyVersion.EndpointName, msg.APIVersion, "Version not supported")
+		} else if msg.Endpoint == "" {
+			rpl.addUnsupported(msg.RequestMethod+" "+msg.RequestPath, "N.A.", "Request path not supported")
+		} else {
+			rpl.addUnsupported(msg.Endpoint, "N.A.", "Endpoint not supported")
+		}
+
+		return
+	}
+
+	messageResult := rpl.mpw.getMessageResult(&msg, validationSettings)
+	rpl.lrm.AppendResult(msg, *messageResult, *validationSettings)
+
+	transmitter := messageResult.TransmitterID
+	if transmitter == "" {
+		transmitter = rpl.cm.settings.ApplicationSettings.OrganisationID
+	}
+
+	if _, ok := rpl.results[transmitter]; !ok {
+		rpl.results[transmitter] = TransmitterResults{
+			TransmitterID:  transmitter,
+			GroupedResults: make(map[string][]MessageResult),
+		}
+	}
+
+	rpl.results[transmitter].GroupedResults[messageResult.ServerID] = append(rpl.results[transmitter].GroupedResults[messageResult.ServerID], *messageResult)
+}
+
+// addUnsupported counts a message for an endpoint or version not supported
+//
+// Parameters:
+//   - endpoint: Name of the endpoint
+//   - version: Version requested
+//   - errorMessage: Reason of the error
+//
+// Returns:
+func (rpl *Replayer) addUnsupported(endpoint string, version string, errorMessage string) {
+	key := endpoint + "|" + version
+	if _, ok := rpl.unsupported[key]; !ok {
+		rpl.unsupported[key] = &models.UnsupportedEndpoint{EndpointName: endpoint, Version: version, Error: errorMessage}
+	}
+
+	rpl.unsupported[key].Count++
+}
+
+// WriteSummary stores the summary of the replay as a list of reports, one by transmitter, and the error samples
+// in the same format as the local results
+//
+// Returns:
+//   - string: Path of the summary file
+//   - error: error if the files could not be written
+func (rpl *Replayer) WriteSummary() (string, error) {
+	unsupported := make([]models.UnsupportedEndpoint, 0)
+	for _, endpoint := range rpl.unsupported {
+		unsupported = append(unsupported, *endpoint)
+	}
+
+	reports := make([]models.Report, 0)
+	for _, transmitterResult := range rpl.results {
+		report := models.Report{
+			DataOwnerID:          rpl.cm.settings.ApplicationSettings.OrganisationID,
+			ClientID:             transmitterResult.TransmitterID,
+			ServerSummary:        rpl.rp.getSummary(transmitterResult.GroupedResults),
+			UnsupportedEndpoints: unsupported,
+		}
+
+		report.ApplicationConfiguration.ConfigurationUpdateStatus.ConfigurationVersion = rpl.cm.GetSnapshot().GetVersion()
+		report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "replay.Date", Value: time.Now().String()})
+		report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "replay.SkippedMessages", Value: strconv.Itoa(rpl.skipped)})
+		reports = append(reports, report)
+	}
+
+	if len(reports) == 0 && len(unsupported) > 0 {
+		reports = append(reports, models.Report{DataOwnerID: rpl.cm.settings.ApplicationSettings.OrganisationID, UnsupportedEndpoints: unsupported})
+	}
+
+	err := os.MkdirAll(rpl.directory, 0750)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(rpl.directory, replayReportFile)
+	err = os.WriteFile(path, data, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	rpl.lrm.Flush()
+	return path, nil
+}
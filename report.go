@@ -23,11 +23,24 @@ type ConfigurationUpdateError struct {
 // ConfigurationUpdateStatus Stores the information for the configuration update status
 type ConfigurationUpdateStatus struct {
 	ConfigurationVersion     string                     // Version of the configuration
+	ConfigurationSource      string                     // Source of the configuration in use (SERVER, CACHE, BUNDLE), CACHE and BUNDLE indicate the server was not reachable
 	LastExecutionDate        time.Time                  // Indicates the data execution of the configuration update
 	LastUpdatedDate          time.Time                  // Indicates the data of the las successful configuration update
 	ConfigurationUpdateError []ConfigurationUpdateError // List of error messages if any durin the update process
 }
 
+// ReportSubmissionError Stores the information of an error returned by the server while sending a report
+type ReportSubmissionError struct {
+	ErrorDate    time.Time // Date of the error
+	StatusCode   int       // HTTP status code returned by the server, 0 if the server could not be reached
+	ErrorMessage string    // Description of the error
+}
+
+// ReportSubmissionStatus Stores the information of the errors found while sending the previous reports
+type ReportSubmissionStatus struct {
+	ReportSubmissionError []ReportSubmissionError // List of errors found while sending the reports
+}
+
 // ApplicationConfiguration Contains the information of the actual configuration of the application
 type ApplicationConfiguration struct {
 	ApplicationVersion        string                    // Version of the application
@@ -37,6 +50,7 @@ type ApplicationConfiguration struct {
 	ReportExecutionNumber     string                    // Report Execution Number limit of the application
 	ApplicationMode           string                    // Mode of the application - TRANSMITTER / RECEIVER
 	ApplicationID             string                    // unique identifier for the application
+	ReportSubmissionStatus    ReportSubmissionStatus    // Errors found while sending the previous reports
 }
 
 // UnsupportedEndpoint shows the list of unsupported endpoints requested to the API
@@ -70,6 +84,7 @@ type EndPointSummaryDetail struct {
 // EndPointSummary Contains a summary for a specific endpoint
 type EndPointSummary struct {
 	EndpointName     string                  // Name of the endpoint
+	Version          string                  // Version of the API used for the validation
 	TotalRequests    int                     // Total number of requests
 	ValidationErrors int                     // Total number of validation errors
 	Detail           []EndPointSummaryDetail // Detail of the errors
@@ -0,0 +1,350 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+	"github.com/google/uuid"
+)
+
+const (
+	outboxExtension     = ".json"          // Extension of the files stored in the outbox
+	outboxRetryPeriod   = 30 * time.Second // Time between the checks for reports to retry
+	outboxInitialDelay  = 30 * time.Second // Delay for the first retry of a report
+	outboxMaxRetryDelay = 60 * time.Minute // Max delay between retries of a report
+	outboxSendTimeout   = 60 * time.Second // Max time to send a report from the outbox
+	outboxTimeFormat    = "20060102150405" // Time format used in the name of the outbox files
+)
+
+// outboxEntry is the information stored for each report waiting to be sent
+type outboxEntry struct {
+	CreatedAt   time.Time     // Date the report failed for the first time
+	Attempts    int           // Number of times the report was sent
+	NextAttempt time.Time     // Date of the next retry
+	Report      models.Report // Report to be sent
+}
+
+// ReportOutbox stores the reports that could not be sent and retries them with exponential backoff
+type ReportOutbox struct {
+	crosscutting.OFBStruct
+	mqdServer services.ReportServer // Report server for MQD
+	cm        *ConfigurationManager // Manager for application settings
+	mutex     sync.Mutex            // Mutex for thread-safe access to the outbox files
+	onError   func(err error)       // Records the errors found while retrying the reports
+}
+
+// NewReportOutbox creates a new report outbox
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - mqdServer: MQD Server to send the reports
+//   - cm: Configuration manager
+//   - onError: Function to record the errors found while retrying the reports
+//
+// Returns:
+//   - *ReportOutbox: New report outbox
+func NewReportOutbox(logger log.Logger, mqdServer services.ReportServer, cm *ConfigurationManager, onError func(err error)) *ReportOutbox {
+	return &ReportOutbox{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.ReportOutbox",
+			Logger: logger,
+		},
+		mqdServer: mqdServer,
+		cm:        cm,
+		onError:   onError,
+	}
+}
+
+// Add stores a report that could not be sent so it can be retried later
+//
+// Parameters:
+//   - report: Report to be stored
+//
+// Returns:
+//   - error: error if the report could not be stored
+func (ro *ReportOutbox) Add(report models.Report) error {
+	ro.mutex.Lock()
+	defer ro.mutex.Unlock()
+
+	directory := ro.cm.GetOutboxDirectory()
+	if err := os.MkdirAll(directory, 0750); err != nil {
+		return fmt.Errorf("failed to create outbox folder %s: %w", directory, err)
+	}
+
+	now := time.Now()
+	entry := outboxEntry{CreatedAt: now, Attempts: 1, Report: report}
+	entry.NextAttempt = now.Add(ro.getRetryDelay(entry.Attempts))
+	fileName := fmt.Sprintf("%s-%s%s", now.Format(outboxTimeFormat), uuid.New().String(), outboxExtension)
+	err := ro.writeEntry(filepath.Join(directory, fileName), entry)
+	if err != nil {
+		return err
+	}
+
+	ro.Logger.Info("Report stored in outbox: "+fileName, ro.Pack, "Add")
+	ro.enforceLimits()
+	return nil
+}
+
+// StartRetryProcess starts the periodic process that retries the reports stored in the outbox
+//
+// Parameters:
+//
+// Returns:
+func (ro *ReportOutbox) StartRetryProcess() {
+	ro.Logger.Info("Starting report outbox retry process", ro.Pack, "StartRetryProcess")
+	ro.mutex.Lock()
+	ro.enforceLimits()
+	ro.mutex.Unlock()
+
+	ticker := time.NewTicker(outboxRetryPeriod)
+	for range ticker.C {
+		ro.retryReports()
+	}
+}
+
+// retryReports sends the reports that reached their next attempt date
+//
+// Parameters:
+//
+// Returns:
+func (ro *ReportOutbox) retryReports() {
+	ro.mutex.Lock()
+	files := ro.listFiles()
+	ro.mutex.Unlock()
+
+	for _, file := range files {
+		ro.retryReport(file)
+	}
+
+	ro.mutex.Lock()
+	monitoring.SetReportOutboxDepth(len(ro.listFiles()))
+	ro.mutex.Unlock()
+}
+
+// retryReport sends a report from the outbox if it reached its next attempt date, the mutex is released while
+// the report is sent so new reports can be stored in the meantime
+//
+// Parameters:
+//   - file: path of the outbox file
+//
+// Returns:
+func (ro *ReportOutbox) retryReport(file string) {
+	ro.mutex.Lock()
+	entry, err := ro.readEntry(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ro.Logger.Error(err, "error reading outbox file, removing: "+file, ro.Pack, "retryReport")
+			ro.removeFile(file)
+		}
+
+		ro.mutex.Unlock()
+		return
+	}
+
+	ro.mutex.Unlock()
+	if time.Now().Before(entry.NextAttempt) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), outboxSendTimeout)
+	err = ro.mqdServer.SendReport(ctx, entry.Report)
+	cancel()
+
+	ro.mutex.Lock()
+	defer ro.mutex.Unlock()
+	if err == nil {
+		ro.Logger.Info("Report from outbox sent: "+filepath.Base(file), ro.Pack, "retryReport")
+		ro.removeFile(file)
+		return
+	}
+
+	ro.Logger.Error(err, "Error sending report from outbox", ro.Pack, "retryReport")
+	ro.onError(err)
+	var rejectedError *services.RejectedReportError
+	if errors.As(err, &rejectedError) {
+		ro.Logger.Warning("Report rejected by the server, removing from outbox: "+filepath.Base(file), ro.Pack, "retryReport")
+		ro.removeFile(file)
+		return
+	}
+
+	// The report may have been discarded by the outbox limits while it was sent
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return
+	}
+
+	entry.Attempts++
+	delay := ro.getRetryDelay(entry.Attempts)
+	var rateLimitedError *services.RateLimitedError
+	if errors.As(err, &rateLimitedError) && rateLimitedError.RetryAfter > delay {
+		delay = rateLimitedError.RetryAfter
+	}
+
+	entry.NextAttempt = time.Now().Add(delay)
+	if err := ro.writeEntry(file, *entry); err != nil {
+		ro.Logger.Error(err, "error updating outbox file", ro.Pack, "retryReport")
+	}
+}
+
+// getRetryDelay calculates the delay before the next attempt using exponential backoff with jitter
+//
+// Parameters:
+//   - attempts: Number of attempts already executed
+//
+// Returns:
+//   - time.Duration: delay for the next attempt
+func (ro *ReportOutbox) getRetryDelay(attempts int) time.Duration {
+	delay := outboxInitialDelay
+	for i := 1; i < attempts && delay < outboxMaxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > outboxMaxRetryDelay {
+		delay = outboxMaxRetryDelay
+	}
+
+	// Use a random delay between half and the full delay to spread the retries of different clients
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1)) // #nosec G404 -- jitter does not require cryptographic randomness
+}
+
+// enforceLimits removes the reports older than the configured max age, and the oldest reports when the outbox exceeds its max size
+//
+// Parameters:
+//
+// Returns:
+func (ro *ReportOutbox) enforceLimits() {
+	files := ro.listFiles()
+	maxAge := ro.cm.GetOutboxMaxAge()
+	maxReports := ro.cm.GetOutboxMaxReports()
+	remaining := make([]string, 0, len(files))
+	for _, file := range files {
+		createdAt, err := time.ParseInLocation(outboxTimeFormat, strings.SplitN(filepath.Base(file), "-", 2)[0], time.Local)
+		if err == nil && time.Since(createdAt) > maxAge {
+			ro.Logger.Warning("Discarding report older than the outbox max age: "+filepath.Base(file), ro.Pack, "enforceLimits")
+			ro.removeFile(file)
+			continue
+		}
+
+		remaining = append(remaining, file)
+	}
+
+	for len(remaining) > maxReports {
+		ro.Logger.Warning("Outbox is full, discarding report: "+filepath.Base(remaining[0]), ro.Pack, "enforceLimits")
+		ro.removeFile(remaining[0])
+		remaining = remaining[1:]
+	}
+
+	monitoring.SetReportOutboxDepth(len(remaining))
+}
+
+// listFiles returns the files stored in the outbox, oldest first
+//
+// Parameters:
+//
+// Returns:
+//   - []string: paths of the outbox files
+func (ro *ReportOutbox) listFiles() []string {
+	directory := ro.cm.GetOutboxDirectory()
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ro.Logger.Error(err, "error reading outbox folder", ro.Pack, "listFiles")
+		}
+
+		return nil
+	}
+
+	result := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), outboxExtension) {
+			result = append(result, filepath.Join(directory, entry.Name()))
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// readEntry reads an outbox file
+//
+// Parameters:
+//   - file: path of the file
+//
+// Returns:
+//   - *outboxEntry: entry read
+//   - error: error if any
+func (ro *ReportOutbox) readEntry(file string) (*outboxEntry, error) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, err
+	}
+
+	var entry outboxEntry
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// writeEntry writes an outbox file, the content is written to a temporary file first to avoid partial files
+//
+// Parameters:
+//   - file: path of the file
+//   - entry: entry to be written
+//
+// Returns:
+//   - error: error if any
+func (ro *ReportOutbox) writeEntry(file string, entry outboxEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	tmpFile := file + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", tmpFile, err)
+	}
+
+	return os.Rename(tmpFile, file)
+}
+
+// removeFile removes an outbox file
+//
+// Parameters:
+//   - file: path of the file
+//
+// Returns:
+func (ro *ReportOutbox) removeFile(file string) {
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		ro.Logger.Error(err, "error removing outbox file: "+file, ro.Pack, "removeFile")
+	}
+}
+
+// getDepth returns the number of reports waiting in the outbox
+//
+// Parameters:
+//
+// Returns:
+//   - string: number of reports
+func (ro *ReportOutbox) getDepth() string {
+	ro.mutex.Lock()
+	defer ro.mutex.Unlock()
+	return strconv.Itoa(len(ro.listFiles()))
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/errorhandling"
+)
+
+const (
+	maxErrorBodyLength = 500 // Max number of characters of the response body kept in an error
+)
+
+// AuthenticationError is returned when the report server rejects the access token
+type AuthenticationError struct {
+	StatusCode int // HTTP status code returned by the server
+}
+
+// Error returns the description of the error
+func (e *AuthenticationError) Error() string {
+	return "report server authentication failed, status code: " + strconv.Itoa(e.StatusCode)
+}
+
+// RejectedReportError is returned when the report server rejects the payload sent, retrying the same payload will fail again
+type RejectedReportError struct {
+	StatusCode  int                         // HTTP status code returned by the server
+	ServerError errorhandling.ErrorResponse // Error returned by the server, empty if the body could not be parsed
+}
+
+// Error returns the description of the error
+func (e *RejectedReportError) Error() string {
+	message := "report rejected by server, status code: " + strconv.Itoa(e.StatusCode)
+	if e.ServerError.Error != "" {
+		message += ", error: " + e.ServerError.Error
+	}
+
+	if e.ServerError.ErrorDescription != "" {
+		message += ", description: " + e.ServerError.ErrorDescription
+	}
+
+	return message
+}
+
+// ServerUnavailableError is returned when the report server could not be reached or failed to process the request
+type ServerUnavailableError struct {
+	StatusCode int   // HTTP status code returned by the server, 0 if the server could not be reached
+	Err        error // Error returned by the HTTP client if any
+}
+
+// Error returns the description of the error
+func (e *ServerUnavailableError) Error() string {
+	if e.Err != nil {
+		return "report server unavailable: " + e.Err.Error()
+	}
+
+	return "report server unavailable, status code: " + strconv.Itoa(e.StatusCode)
+}
+
+// Unwrap returns the error returned by the HTTP client
+func (e *ServerUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// RateLimitedError is returned when the report server is limiting the requests of the client
+type RateLimitedError struct {
+	RetryAfter time.Duration // Time requested by the server before retrying, 0 if not informed
+}
+
+// Error returns the description of the error
+func (e *RateLimitedError) Error() string {
+	return "report server rate limit reached, retry after: " + e.RetryAfter.String()
+}
+
+// getResponseError creates the typed error for a response with an unexpected status code
+//
+// Parameters:
+//   - response: Response received from the server
+//   - body: Body of the response
+//
+// Returns:
+//   - error: typed error for the status code
+func getResponseError(response *http.Response, body []byte) error {
+	switch {
+	case response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden:
+		return &AuthenticationError{StatusCode: response.StatusCode}
+	case response.StatusCode == http.StatusTooManyRequests:
+		result := &RateLimitedError{}
+		seconds, err := strconv.Atoi(response.Header.Get("Retry-After"))
+		if err == nil && seconds > 0 {
+			result.RetryAfter = time.Duration(seconds) * time.Second
+		}
+
+		return result
+	case response.StatusCode >= http.StatusBadRequest && response.StatusCode < http.StatusInternalServerError:
+		result := &RejectedReportError{StatusCode: response.StatusCode}
+		err := json.Unmarshal(body, &result.ServerError)
+		if err != nil {
+			if len(body) > maxErrorBodyLength {
+				body = body[:maxErrorBodyLength]
+			}
+
+			result.ServerError.ErrorDescription = string(body)
+		}
+
+		return result
+	default:
+		return &ServerUnavailableError{StatusCode: response.StatusCode}
+	}
+}
@@ -2,14 +2,18 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/security/jwt"
 	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
 )
 
@@ -42,45 +46,122 @@ func NewReportServerMQD(logger log.Logger, serverURL string, settings configurat
 				Logger: logger,
 			},
 			serverURL: serverURL,
+			timeouts: HTTPTimeouts{
+				Connect: time.Duration(settings.ReportSettings.ConnectTimeout) * time.Second,
+				Read:    time.Duration(settings.ReportSettings.ReadTimeout) * time.Second,
+				Request: time.Duration(settings.ReportSettings.RequestTimeout) * time.Second,
+			},
 		},
 		settings: settings,
 	}
 
-	// result.loadCertificates()
+	result.loadCertificates()
+	result.loadClientAuthentication()
+	result.initTokenManager(settings.ApplicationSettings.OrganisationID, settings.SecuritySettings.TokenRefreshFraction, time.Duration(settings.SecuritySettings.TokenClockSkew)*time.Second)
+	result.loadTokenVerifier()
 	return result
 }
 
+// loadTokenVerifier Enables the verification of the access tokens against the key set of the central server if configured
+//
+// Parameters:
+//
+// Returns:
+func (rs *ReportServerMQD) loadTokenVerifier() {
+	security := rs.settings.SecuritySettings
+	if !security.VerifyAccessToken {
+		return
+	}
+
+	jwksURL := security.JWKSURL
+	rs.Logger.Info("Access token verification enabled, key set: "+jwksURL, rs.Pack, "loadTokenVerifier")
+	verifier := jwt.NewJWKSVerifier(rs.Logger, func(ctx context.Context) ([]byte, error) {
+		return rs.executeGet(ctx, jwksURL, 1)
+	}, security.TokenIssuer, security.TokenAudience, time.Duration(security.TokenClockSkew)*time.Second)
+	rs.tokenManager.SetVerifier(verifier)
+}
+
+// loadClientAuthentication Loads the authentication method used on the token request
+//
+// Parameters:
+//
+// Returns:
+func (rs *ReportServerMQD) loadClientAuthentication() {
+	security := rs.settings.SecuritySettings
+	clientAuth, err := NewClientAuthentication(security.TokenAuthMethod, security.ClientSecret, security.ClientAssertionKeyFilePath, security.ClientAssertionKeyID, security.ClientAssertionAlgorithm, security.ClientAssertionAudience)
+	if err != nil {
+		rs.Logger.Fatal(err, "Error loading client authentication", rs.Pack, "loadClientAuthentication")
+	}
+
+	if security.TokenAuthMethod == ClientAuthTLS && rs.certificates == nil {
+		rs.Logger.Warning(ClientAuthTLS+" requires the client certificates to be configured", rs.Pack, "loadClientAuthentication")
+	}
+
+	rs.clientAuth = clientAuth
+}
+
+// loadCertificates Loads the client certificates for mTLS if they are configured
+//
+// Parameters:
+//
+// Returns:
+func (rs *ReportServerMQD) loadCertificates() {
+	security := rs.settings.SecuritySettings
+	if security.ClientCertFilePath == "" && security.ClientPKCS12FilePath == "" && security.CACertFilePath == "" {
+		rs.Logger.Info("Client certificates not configured, mTLS disabled", rs.Pack, "loadCertificates")
+		return
+	}
+
+	certificates, err := NewClientCertificates(rs.Logger, security.ClientCertFilePath, security.ClientKeyFilePath, security.ClientPKCS12FilePath, security.ClientPKCS12Password, security.CACertFilePath)
+	if err != nil {
+		rs.Logger.Fatal(err, "Error loading client certificates", rs.Pack, "loadCertificates")
+	}
+
+	rs.certificates = certificates
+}
+
 // SendReport Sends a report to the central server
 //
 // Parameters:
+//   - ctx: Context of the request, cancelling it aborts the submission
 //   - report: Report to be sent
 //
 // Returns:
 //   - error: Error if any
-func (rs *ReportServerMQD) SendReport(report models.Report) error {
+func (rs *ReportServerMQD) SendReport(ctx context.Context, report models.Report) error {
 	rs.Logger.Info("Sending report to central Server", rs.Pack, "sendReportToAPI")
 
-	err := rs.getJWKToken(rs.settings.ApplicationSettings.OrganisationID)
+	token, err := rs.getJWKToken(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = rs.postReport(report)
-	if err != nil {
-		return err
+	err = rs.postReport(ctx, report, token)
+	var authError *AuthenticationError
+	if errors.As(err, &authError) {
+		rs.Logger.Warning("Token rejected by the server, requesting a new token", rs.Pack, "SendReport")
+		token, err = rs.refreshJWKToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = rs.postReport(ctx, report, token)
 	}
 
-	return nil
+	return err
 }
 
 // postReport sends the report to the server using required authorization
 //
 // Parameters:
+//   - ctx: Context of the request
 //   - report: Report to be sent
+//   - token: Token used on the authorization header
 //
 // Returns:
-//   - error: Error if any
-func (rs *ReportServerMQD) postReport(report models.Report) error {
+//   - error: Error if any, AuthenticationError, RejectedReportError, ServerUnavailableError or RateLimitedError
+//     when the server does not accept the report
+func (rs *ReportServerMQD) postReport(ctx context.Context, report models.Report, token *jwt.JWKToken) error {
 	rs.Logger.Info("Posting report", rs.Pack, "postReport")
 
 	httpClient := rs.getHTTPClient()
@@ -91,20 +172,20 @@ func (rs *ReportServerMQD) postReport(report models.Report) error {
 	}
 
 	// Create a new request
-	req, err := http.NewRequest("POST", rs.serverURL+reportPath, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", rs.serverURL+reportPath, bytes.NewBuffer(requestBody))
 	if err != nil {
 		fmt.Println("Error creating request:", err)
 		return err
 	}
 
 	// Set the Authorization header with your token
-	req.Header.Set("Authorization", "Bearer "+rs.token.AccessToken)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
 
 	// Send the request
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		rs.Logger.Error(err, "Error sending report.", rs.Pack, "postReport")
-		return err
+		return &ServerUnavailableError{Err: err}
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -113,19 +194,19 @@ func (rs *ReportServerMQD) postReport(report models.Report) error {
 		}
 	}(resp.Body)
 
+	// Read the body of the message
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
 	// Check the response status code
 	if resp.StatusCode != http.StatusOK {
 		rs.Logger.Warning("Error sending report, Status code: "+fmt.Sprint(resp.StatusCode), rs.Pack, "postReport")
-	} else {
-		// Read the body of the message
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-
-		rs.Logger.Info(string(body), rs.Pack, "postReport")
+		return getResponseError(resp, body)
 	}
 
+	rs.Logger.Info(string(body), rs.Pack, "postReport")
 	return nil
 }
 
@@ -140,7 +221,7 @@ func (rs *ReportServerMQD) postReport(report models.Report) error {
 func (rs *ReportServerMQD) LoadAPIConfigurationFile(filePath string) ([]byte, error) {
 	rs.Logger.Info("Loading API configuration", rs.Pack, "loadAPIConfiguration")
 	serverPath := rs.serverURL + settingsPath + "/" + filePath
-	return rs.executeGet(serverPath, 3)
+	return rs.executeGet(context.Background(), serverPath, 3)
 }
 
 // LoadConfigurationSettings Loads the main configuration file for the application
@@ -154,7 +235,7 @@ func (rs *ReportServerMQD) LoadConfigurationSettings() (*models.ConfigurationSet
 	rs.Logger.Info("Loading ConfigurationSettings", rs.Pack, "LoadConfigurationSettings")
 	serverPath := rs.serverURL + settingsPath + "/" + configurationSettingsFile
 
-	body, err := rs.executeGet(serverPath, 3)
+	body, err := rs.executeGet(context.Background(), serverPath, 3)
 	if err != nil {
 		return nil, err
 	}
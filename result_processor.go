@@ -1,7 +1,10 @@
 package application
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
 	"strconv"
 	"sync"
 	"time"
@@ -15,13 +18,15 @@ import (
 
 // MessageResult contains the information for a validation
 type MessageResult struct {
-	TransmitterID      string              // Organisation ID of the transmitter
-	Endpoint           string              // Name of the endpoint
-	HTTPMethod         string              // Type of HTTP method
-	Result             bool                // Indicates the result of the validation (True= Valid  ok)
-	ServerID           string              // Identifies the server requesting the information
-	Errors             map[string][]string // Details for the errors found during the validation
-	XFapiInteractionID string
+	TransmitterID        string              // Organisation ID of the transmitter
+	Endpoint             string              // Name of the endpoint
+	HTTPMethod           string              // Type of HTTP method
+	Result               bool                // Indicates the result of the validation (True= Valid  ok)
+	ServerID             string              // Identifies the server requesting the information
+	Errors               map[string][]string // Details for the errors found during the validation
+	XFapiInteractionID   string
+	APIVersion           string // Version of the API used to validate the message
+	ConfigurationVersion string // Version of the configuration used to validate the message
 }
 
 // EndpointSummary contains the summary information for the validations by endpoint
@@ -40,10 +45,15 @@ type ErrorDetail struct {
 
 // TransmitterResults Stores the result for a specific transmitterID
 type TransmitterResults struct {
-	TransmitterID  string
-	GroupedResults map[string][]MessageResult // slice to store grouped results
+	TransmitterID    string
+	GroupedResults   map[string][]MessageResult // slice to store grouped results
+	acknowledgements []*Message                 // Messages of the results, acknowledged once the report is sent or stored
 }
 
+const (
+	maxSubmissionErrors = 50 // Max number of submission errors kept for the next report
+)
+
 var (
 	resultProcessorSingleton ResultProcessor // Singleton instance of the ResultProcessor
 	resultProcessorMutex     = sync.Mutex{}  // Mutex for thread-safe access to messageResults
@@ -54,9 +64,15 @@ var (
 // ResultProcessor struct in charge of processing results
 type ResultProcessor struct {
 	crosscutting.OFBStruct
-	reportStartTime time.Time             // Datetime of the start of the report
-	mqdServer       services.ReportServer // Report server for MQD
-	cm              *ConfigurationManager // Manager for application settings
+	reportStartTime time.Time                      // Datetime of the start of the report
+	mqdServer       services.ReportServer          // Report server for MQD
+	cm              *ConfigurationManager          // Manager for application settings
+	qm              *QueueManager                  // Queue manager to acknowledge the messages once their results are reported
+	outbox          *ReportOutbox                  // Outbox for the reports that could not be sent
+	submissionMutex sync.Mutex                     // Mutex for thread-safe access to submissionErrors
+	submissionErrs  []models.ReportSubmissionError // Errors found while sending reports, included on the next report
+	stop            chan struct{}                  // Closed to stop the periodic report process
+	stopped         chan struct{}                  // Closed once the periodic report process has finished
 }
 
 // GetResultProcessor returns the singleton instance of the ResultProcessor
@@ -65,10 +81,11 @@ type ResultProcessor struct {
 //   - logger: Logger to be used by the processor
 //   - mqdServer: MQD Server to send the results
 //   - cm: Configuration manager
+//   - qm: Queue manager of the processed messages
 //
 // Returns:
 //   - *ResultProcessor: New result processor created
-func GetResultProcessor(logger log.Logger, mqdServer services.ReportServer, cm *ConfigurationManager) *ResultProcessor {
+func GetResultProcessor(logger log.Logger, mqdServer services.ReportServer, cm *ConfigurationManager, qm *QueueManager) *ResultProcessor {
 	if resultProcessorSingleton.Pack == "" {
 		resultProcessorSingleton = ResultProcessor{
 			OFBStruct: crosscutting.OFBStruct{
@@ -76,21 +93,28 @@ func GetResultProcessor(logger log.Logger, mqdServer services.ReportServer, cm *
 				Logger: logger,
 			},
 			cm:              cm,
+			qm:              qm,
 			mqdServer:       mqdServer,
 			reportStartTime: time.Time{},
+			stop:            make(chan struct{}),
+			stopped:         make(chan struct{}),
 		}
+
+		resultProcessorSingleton.outbox = NewReportOutbox(logger, mqdServer, cm, resultProcessorSingleton.appendSubmissionError)
 	}
 
 	return &resultProcessorSingleton
 }
 
-// AppendResult is for appending a message result
+// AppendResult is for appending a message result, the message is acknowledged once the report with the result
+// is sent or stored in the outbox
 //
 // Parameters:
 //   - result: Message result to be included
+//   - msg: Message validated
 //
 // Returns:
-func (rp *ResultProcessor) AppendResult(result *MessageResult) {
+func (rp *ResultProcessor) AppendResult(result *MessageResult, msg *Message) {
 	resultProcessorMutex.Lock()
 	totalResults++
 
@@ -108,6 +132,11 @@ func (rp *ResultProcessor) AppendResult(result *MessageResult) {
 
 	txResult := txGroupedResults[transmitterID]
 	txResult.GroupedResults[result.ServerID] = append(txResult.GroupedResults[result.ServerID], *result)
+	if msg.queueID != 0 || msg.onAcknowledge != nil {
+		// Only the acknowledge information is kept, the content of the message is not needed anymore
+		txResult.acknowledgements = append(txResult.acknowledgements, &Message{queueID: msg.queueID, onAcknowledge: msg.onAcknowledge})
+	}
+
 	txGroupedResults[transmitterID] = txResult
 
 	rp.Logger.Debug("Total grouped Results for TransmitterID: ["+transmitterID+"] in ServerID ["+result.ServerID+"] :"+strconv.Itoa(len(txResult.GroupedResults[result.ServerID])), rp.Pack, "getAndClearResults")
@@ -134,14 +163,17 @@ func (rp *ResultProcessor) getAndClearResults() map[string]TransmitterResults {
 	return txGroupedResults
 }
 
-// StartResultsProcessor starts the periodic process that prints total results and clears them every 2 minutes
+// StartResultsProcessor starts the periodic process that prints total results and clears them every 2 minutes,
+// the process runs until the result processor is flushed
 //
 // Parameters:
 //
 // Returns:
 func (rp *ResultProcessor) StartResultsProcessor() {
-	rp.Logger.Info("Starting result processor, ReportExecutionWindow: "+strconv.Itoa(rp.cm.ConfigurationSettings.ReportSettings.ReportExecutionWindow), rp.Pack, "StartResultsProcessor")
+	defer close(rp.stopped)
+	rp.Logger.Info("Starting result processor, ReportExecutionWindow: "+strconv.Itoa(rp.cm.GetReportExecutionWindow()), rp.Pack, "StartResultsProcessor")
 	rp.reportStartTime = time.Now()
+	go rp.outbox.StartRetryProcess()
 	timeWindow := time.Duration(rp.cm.GetReportExecutionWindow()) * time.Minute
 	// create an empty result for the initial run
 	newResult := TransmitterResults{
@@ -150,15 +182,18 @@ func (rp *ResultProcessor) StartResultsProcessor() {
 
 	txGroupedResults[rp.cm.settings.ApplicationSettings.OrganisationID] = newResult
 	// Send an initial report for observability.
-	rp.processAndSendResults()
+	rp.processAndSendResults(context.Background())
 	ticker := time.NewTicker(timeWindow)
+	defer func() { ticker.Stop() }()
 	for {
 		select {
+		case <-rp.stop:
+			return
 		case <-ticker.C:
-			rp.processAndSendResults()
+			rp.processAndSendResults(context.Background())
 		case <-time.After(5 * time.Second):
 			if totalResults >= rp.cm.GetSendOnReportNumber() {
-				rp.processAndSendResults()
+				rp.processAndSendResults(context.Background())
 				ticker.Stop()                       // Stop the current ticker
 				ticker = time.NewTicker(timeWindow) // Restart the ticker
 			}
@@ -166,12 +201,32 @@ func (rp *ResultProcessor) StartResultsProcessor() {
 	}
 }
 
+// Flush stops the periodic report process and sends the results collected since the last report
+//
+// Parameters:
+//   - ctx: Context to cancel the submission of the report
+//
+// Returns:
+func (rp *ResultProcessor) Flush(ctx context.Context) {
+	close(rp.stop)
+	select {
+	case <-rp.stopped:
+	case <-ctx.Done():
+		rp.Logger.Warning("Periodic report process did not stop before the grace period", rp.Pack, "Flush")
+		return
+	}
+
+	rp.Logger.Info("Sending final report", rp.Pack, "Flush")
+	rp.processAndSendResults(ctx)
+}
+
 // processAndSendResults Processes the current results (creates a summary report) and sends it to the main server
 //
 // Parameters:
+//   - ctx: Context to cancel the submission of the report
 //
 // Returns:
-func (rp *ResultProcessor) processAndSendResults() {
+func (rp *ResultProcessor) processAndSendResults(ctx context.Context) {
 	rp.Logger.Info("Processing and sending results", "result", "processAndSendResults")
 	processStartTime := time.Now()
 	report := models.Report{DataOwnerID: rp.cm.settings.ApplicationSettings.OrganisationID}
@@ -185,17 +240,103 @@ func (rp *ResultProcessor) processAndSendResults() {
 		report.ServerSummary = rp.getSummary(transmitterResult.GroupedResults)
 		rp.Logger.Debug("Total ServerSummary process :"+strconv.Itoa(len(report.ServerSummary)), rp.Pack, "processAndSendResults")
 		report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.ReportGenerationTime", Value: time.Since(processStartTime).String()})
-		err := rp.mqdServer.SendReport(report)
+		err := rp.mqdServer.SendReport(ctx, report)
 		if err != nil {
 			rp.Logger.Error(err, "Error sending report", rp.Pack, "processAndSendResults")
-			return
+			rp.appendSubmissionError(err)
+			var rejectedError *services.RejectedReportError
+			if errors.As(err, &rejectedError) {
+				// The server will reject the same payload again, there is no reason to retry it
+				rp.acknowledge(transmitterResult.acknowledgements)
+				continue
+			}
+
+			err = rp.outbox.Add(report)
+			if err != nil {
+				// The messages are not acknowledged, so they are processed again after a restart
+				rp.Logger.Error(err, "Error storing report in the outbox", rp.Pack, "processAndSendResults")
+				continue
+			}
+
+			rp.acknowledge(transmitterResult.acknowledgements)
+			continue
 		}
+
+		rp.acknowledge(transmitterResult.acknowledgements)
+		rp.clearSubmissionErrors(report.ApplicationConfiguration.ReportSubmissionStatus.ReportSubmissionError)
 		rp.printReport(report)
 	}
 
 	rp.Logger.Info("processAndSendResults -> Process finished", "server", "postReport")
 }
 
+// acknowledge confirms the messages of a report sent or stored in the outbox
+//
+// Parameters:
+//   - messages: Messages of the results included on the report
+//
+// Returns:
+func (rp *ResultProcessor) acknowledge(messages []*Message) {
+	for _, msg := range messages {
+		rp.qm.Acknowledge(msg)
+	}
+}
+
+// appendSubmissionError records an error found while sending a report, so it can be included on the next report
+//
+// Parameters:
+//   - err: Error returned by the report server
+//
+// Returns:
+func (rp *ResultProcessor) appendSubmissionError(err error) {
+	submissionError := models.ReportSubmissionError{ErrorDate: time.Now(), ErrorMessage: err.Error()}
+	var authError *services.AuthenticationError
+	var rejectedError *services.RejectedReportError
+	var unavailableError *services.ServerUnavailableError
+	var rateLimitedError *services.RateLimitedError
+	switch {
+	case errors.As(err, &authError):
+		submissionError.StatusCode = authError.StatusCode
+	case errors.As(err, &rejectedError):
+		submissionError.StatusCode = rejectedError.StatusCode
+	case errors.As(err, &unavailableError):
+		submissionError.StatusCode = unavailableError.StatusCode
+	case errors.As(err, &rateLimitedError):
+		submissionError.StatusCode = http.StatusTooManyRequests
+	}
+
+	rp.submissionMutex.Lock()
+	rp.submissionErrs = append(rp.submissionErrs, submissionError)
+	if len(rp.submissionErrs) > maxSubmissionErrors {
+		rp.submissionErrs = rp.submissionErrs[len(rp.submissionErrs)-maxSubmissionErrors:]
+	}
+	rp.submissionMutex.Unlock()
+}
+
+// clearSubmissionErrors removes the submission errors already delivered to the server
+//
+// Parameters:
+//   - delivered: Errors included on the report delivered
+//
+// Returns:
+func (rp *ResultProcessor) clearSubmissionErrors(delivered []models.ReportSubmissionError) {
+	if len(delivered) == 0 {
+		return
+	}
+
+	lastDelivered := delivered[len(delivered)-1].ErrorDate
+	rp.submissionMutex.Lock()
+	remaining := make([]models.ReportSubmissionError, 0)
+	for _, submissionError := range rp.submissionErrs {
+		if submissionError.ErrorDate.After(lastDelivered) {
+			remaining = append(remaining, submissionError)
+		}
+	}
+
+	rp.submissionErrs = remaining
+	rp.submissionMutex.Unlock()
+}
+
 // updateMetrics Updates the metrics for the report
 //
 // Parameters:
@@ -213,6 +354,8 @@ func (rp *ResultProcessor) updateMetrics(report *models.Report) {
 	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.MemoryUsageMax", Value: systemMetrics.MaxUsedMemory})
 	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.CPUNumber", Value: systemMetrics.AllowedCPUs})
 	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.ResponseTimeAvg", Value: systemMetrics.AverageResponseTime})
+	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.DroppedMessages", Value: systemMetrics.DroppedMessages})
+	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.ReportOutboxDepth", Value: rp.outbox.getDepth()})
 
 	report.ApplicationConfiguration.ApplicationVersion = monitoring.Version
 	report.ApplicationConfiguration.Environment = rp.cm.settings.ConfigurationSettings.Environment
@@ -228,7 +371,12 @@ func (rp *ResultProcessor) updateMetrics(report *models.Report) {
 		})
 	}
 
-	report.ApplicationConfiguration.ConfigurationUpdateStatus.ConfigurationVersion = rp.cm.ConfigurationSettings.Version
+	rp.submissionMutex.Lock()
+	report.ApplicationConfiguration.ReportSubmissionStatus.ReportSubmissionError = append([]models.ReportSubmissionError(nil), rp.submissionErrs...)
+	rp.submissionMutex.Unlock()
+
+	report.ApplicationConfiguration.ConfigurationUpdateStatus.ConfigurationVersion = rp.cm.GetSnapshot().GetVersion()
+	report.ApplicationConfiguration.ConfigurationUpdateStatus.ConfigurationSource = rp.cm.GetConfigurationSource()
 	report.ApplicationConfiguration.ApplicationMode = rp.cm.settings.ApplicationSettings.Mode
 
 	ue := monitoring.GetAndCleanUnsupportedEndpoints()
@@ -270,7 +418,7 @@ func (rp *ResultProcessor) getSummary(results map[string][]MessageResult) []mode
 	return result
 }
 
-// updateEndpointSummary Updates the summary for a specific endpoint
+// updateEndpointSummary Updates the summary for a specific endpoint and API version
 //
 // Parameters:
 //   - endpointSummary: summary to be updated
@@ -279,10 +427,10 @@ func (rp *ResultProcessor) getSummary(results map[string][]MessageResult) []mode
 // Returns:
 //   - ServerSummary: Summary updated with the result
 func (rp *ResultProcessor) updateEndpointSummary(endpointSummary []models.EndPointSummary, messageResult MessageResult) []models.EndPointSummary {
-	newEPSummary := models.EndPointSummary{EndpointName: messageResult.Endpoint, TotalRequests: 1}
+	newEPSummary := models.EndPointSummary{EndpointName: messageResult.Endpoint, Version: messageResult.APIVersion, TotalRequests: 1}
 	found := false
 	for i, ep := range endpointSummary {
-		if ep.EndpointName == newEPSummary.EndpointName {
+		if ep.EndpointName == newEPSummary.EndpointName && ep.Version == newEPSummary.Version {
 			found = true
 			endpointSummary[i].TotalRequests++
 			if !messageResult.Result {
@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"sync"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+var (
+	schemaRegistrySingleton *SchemaRegistry // Singleton for the schema registry
+	schemaRegistryMutex     = sync.Mutex{}  // Mutex for the singleton variable
+)
+
+// compiledSchema stores a compiled JSON schema and the source used to compile it
+type compiledSchema struct {
+	source string               // JSON Schema used to compile the schema
+	schema *gojsonschema.Schema // Compiled schema
+}
+
+// schemaCache stores the compiled schemas for a specific configuration version
+type schemaCache struct {
+	version string                     // Configuration version of the cached schemas
+	schemas map[string]*compiledSchema // Compiled schemas by key
+}
+
+// SchemaRegistry compiles JSON schemas once and keeps them until the configuration version changes
+type SchemaRegistry struct {
+	pack   string       // Package name
+	logger log.Logger   // Logger
+	mutex  sync.RWMutex // Mutex for thread-safe access to the cache
+	cache  *schemaCache // Cache for the current configuration version
+}
+
+// GetSchemaRegistry returns the schema registry of the application
+//
+// Parameters:
+//   - logger: Logger to be used
+//
+// Returns:
+//   - *SchemaRegistry: Schema registry instance
+func GetSchemaRegistry(logger log.Logger) *SchemaRegistry {
+	schemaRegistryMutex.Lock()
+	defer schemaRegistryMutex.Unlock()
+	if schemaRegistrySingleton == nil {
+		schemaRegistrySingleton = &SchemaRegistry{
+			pack:   "SchemaRegistry",
+			logger: logger,
+			cache:  &schemaCache{schemas: make(map[string]*compiledSchema)},
+		}
+	}
+
+	return schemaRegistrySingleton
+}
+
+// SetVersion sets the configuration version for the registry, all compiled schemas are discarded if the version changes
+//
+// Parameters:
+//   - version: Configuration version in use
+//
+// Returns:
+func (sr *SchemaRegistry) SetVersion(version string) {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+	if sr.cache.version == version {
+		return
+	}
+
+	sr.logger.Info("Clearing compiled schemas for configuration version: "+version, sr.pack, "SetVersion")
+	sr.cache = &schemaCache{version: version, schemas: make(map[string]*compiledSchema)}
+}
+
+// GetSchema returns the compiled schema for the specified key, the schema is compiled if it is not found
+//
+// Parameters:
+//   - key: Identifier of the schema (API / version / endpoint)
+//   - schema: JSON Schema to be compiled
+//
+// Returns:
+//   - *gojsonschema.Schema: Compiled schema
+//   - error: Error if the schema can not be compiled
+func (sr *SchemaRegistry) GetSchema(key string, schema string) (*gojsonschema.Schema, error) {
+	sr.mutex.RLock()
+	cache := sr.cache
+	entry, ok := cache.schemas[key]
+	sr.mutex.RUnlock()
+	if ok && entry.source == schema {
+		return entry.schema, nil
+	}
+
+	sr.logger.Debug("Compiling schema: "+key, sr.pack, "GetSchema")
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+	if err != nil {
+		sr.logger.Error(err, "error compiling schema: "+key, sr.pack, "GetSchema")
+		return nil, err
+	}
+
+	sr.mutex.Lock()
+	// Only store the schema if the version was not changed during compilation
+	if sr.cache == cache {
+		cache.schemas[key] = &compiledSchema{source: schema, schema: compiled}
+	}
+	sr.mutex.Unlock()
+
+	return compiled, nil
+}
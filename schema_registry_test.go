@@ -0,0 +1,98 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+// benchmarkSchema is a reduced version of a consents response schema
+const benchmarkSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"required": ["data", "links", "meta"],
+	"properties": {
+		"data": {
+			"type": "object",
+			"required": ["consentId", "creationDateTime", "status", "permissions"],
+			"properties": {
+				"consentId": {"type": "string", "pattern": "^urn:[a-zA-Z0-9][a-zA-Z0-9-]{0,31}:[a-zA-Z0-9()+,\\-.:=@;$_!*'%\\/?#]+$", "maxLength": 256},
+				"creationDateTime": {"type": "string", "format": "date-time"},
+				"status": {"type": "string", "enum": ["AUTHORISED", "AWAITING_AUTHORISATION", "REJECTED"]},
+				"permissions": {
+					"type": "array",
+					"minItems": 1,
+					"items": {"type": "string", "enum": ["ACCOUNTS_READ", "ACCOUNTS_BALANCES_READ", "RESOURCES_READ"]}
+				}
+			}
+		},
+		"links": {
+			"type": "object",
+			"required": ["self"],
+			"properties": {"self": {"type": "string", "format": "uri"}}
+		},
+		"meta": {
+			"type": "object",
+			"required": ["totalRecords", "totalPages"],
+			"properties": {
+				"totalRecords": {"type": "integer"},
+				"totalPages": {"type": "integer"}
+			}
+		}
+	}
+}`
+
+// benchmarkPayload is a valid message for benchmarkSchema
+const benchmarkPayload = `{
+	"data": {
+		"consentId": "urn:bancoex:C1DD33123",
+		"creationDateTime": "2021-05-21T08:30:00Z",
+		"status": "AUTHORISED",
+		"permissions": ["ACCOUNTS_READ", "ACCOUNTS_BALANCES_READ", "RESOURCES_READ"]
+	},
+	"links": {"self": "https://api.banco.com.br/open-banking/api/v1/resource"},
+	"meta": {"totalRecords": 1, "totalPages": 1}
+}`
+
+// BenchmarkSchemaValidation compares the validation compiling the schema on every message with the validation
+// using the schemas compiled by the registry
+func BenchmarkSchemaValidation(b *testing.B) {
+	logger := log.GetLogger()
+	logger.SetLoggingGlobalLevel(log.ErrorLevel)
+
+	var data DynamicStruct
+	err := json.Unmarshal([]byte(benchmarkPayload), &data)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("Uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			result, err := GetSchemaValidator(logger, benchmarkSchema).Validate(data)
+			if err != nil || !result.Valid {
+				b.Fatalf("unexpected result: %v %v", result, err)
+			}
+		}
+	})
+
+	b.Run("Cached", func(b *testing.B) {
+		registry := &SchemaRegistry{
+			pack:   "SchemaRegistry",
+			logger: logger,
+			cache:  &schemaCache{schemas: make(map[string]*compiledSchema)},
+		}
+
+		for i := 0; i < b.N; i++ {
+			schema, err := registry.GetSchema("consents/v1/consents/{consentId}/body", benchmarkSchema)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			result, err := GetCompiledSchemaValidator(logger, schema).Validate(data)
+			if err != nil || !result.Valid {
+				b.Fatalf("unexpected result: %v %v", result, err)
+			}
+		}
+	})
+}
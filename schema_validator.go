@@ -13,9 +13,10 @@ type DynamicStruct map[string]interface{}
 
 // SchemaValidator Validator that uses JSON Schemas
 type SchemaValidator struct {
-	pack   string     // Package name
-	schema string     // JSON Schema
-	logger log.Logger // Logger
+	pack     string               // Package name
+	schema   string               // JSON Schema
+	compiled *gojsonschema.Schema // Compiled JSON Schema, used instead of schema when present
+	logger   log.Logger           // Logger
 }
 
 // GetSchemaValidator is for creating a SchemaValidator
@@ -33,6 +34,22 @@ func GetSchemaValidator(logger log.Logger, schema string) *SchemaValidator {
 	}
 }
 
+// GetCompiledSchemaValidator is for creating a SchemaValidator from an already compiled schema
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - schema: Compiled JSON Schema to be used for validation
+//
+// Returns:
+//   - *SchemaValidator: SchemaValidator instance
+func GetCompiledSchemaValidator(logger log.Logger, schema *gojsonschema.Schema) *SchemaValidator {
+	return &SchemaValidator{
+		pack:     "SchemaValidator",
+		compiled: schema,
+		logger:   logger,
+	}
+}
+
 // Validate is for Validating a dynamic structure using a JSON Schema
 // @author AB
 // @params
@@ -44,13 +61,19 @@ func (sm *SchemaValidator) Validate(data DynamicStruct) (*Result, error) {
 	sm.logger.Info("Starting Validation With Schema", sm.pack, "Validate")
 
 	validationResult := Result{Valid: true}
-	if sm.schema == "" {
+	if sm.schema == "" && sm.compiled == nil {
 		return &validationResult, nil
 	}
 
-	loader := gojsonschema.NewStringLoader(sm.schema)
 	documentLoader := gojsonschema.NewGoLoader(data)
-	result, err := gojsonschema.Validate(loader, documentLoader)
+	var result *gojsonschema.Result
+	var err error
+	if sm.compiled != nil {
+		result, err = sm.compiled.Validate(documentLoader)
+	} else {
+		result, err = gojsonschema.Validate(gojsonschema.NewStringLoader(sm.schema), documentLoader)
+	}
+
 	if err != nil {
 		sm.logger.Error(err, "error validating message", sm.pack, "Validate")
 		return nil, err
@@ -0,0 +1,185 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultRefreshFraction = 0.8              // Default fraction of the token lifetime after which the token is refreshed
+	defaultClockSkew       = 30 * time.Second // Default tolerance for differences between the client and server clocks
+)
+
+// TokenRequester requests a token to the authorization server, a refresh token grant is used when refreshToken is not empty
+type TokenRequester func(ctx context.Context, refreshToken string) (*JWKToken, error)
+
+// TokenManager keeps a valid token, refreshing it before it expires, it is safe for concurrent use
+type TokenManager struct {
+	logger           log.Logger     // Logger to be used
+	requester        TokenRequester // Function used to request new tokens
+	refreshFraction  float64        // Fraction of the token lifetime after which the token is refreshed
+	clockSkew        time.Duration  // Tolerance for differences between the client and server clocks
+	mutex            sync.Mutex     // Mutex to request only one token at a time
+	token            *JWKToken      // Current token
+	refreshAt        time.Time      // Time after which the token must be refreshed
+	refreshExpiresAt time.Time      // Time after which the refresh token can not be used
+	verifier         *JWKSVerifier  // Verifier of the received tokens, nil if verification is disabled
+}
+
+// NewTokenManager creates a new token manager
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - requester: Function used to request new tokens
+//   - refreshFraction: Fraction of the token lifetime after which the token is refreshed (0 - 1), 0.8 if out of range
+//   - clockSkew: Tolerance for differences between the client and server clocks, 30 seconds if not positive
+//
+// Returns:
+//   - *TokenManager: New token manager
+func NewTokenManager(logger log.Logger, requester TokenRequester, refreshFraction float64, clockSkew time.Duration) *TokenManager {
+	if refreshFraction <= 0 || refreshFraction >= 1 {
+		refreshFraction = defaultRefreshFraction
+	}
+
+	if clockSkew <= 0 {
+		clockSkew = defaultClockSkew
+	}
+
+	return &TokenManager{
+		logger:          logger,
+		requester:       requester,
+		refreshFraction: refreshFraction,
+		clockSkew:       clockSkew,
+	}
+}
+
+// GetToken returns a valid token, a new token is requested if the current one is close to expire
+//
+// Parameters:
+//   - ctx: Context of the request
+//
+// Returns:
+//   - *JWKToken: Valid token
+//   - error: error if a new token could not be obtained
+func (tm *TokenManager) GetToken(ctx context.Context) (*JWKToken, error) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	now := time.Now()
+	if tm.token != nil && now.Before(tm.refreshAt) {
+		return tm.token, nil
+	}
+
+	var token *JWKToken
+	var err error
+	if tm.token != nil && tm.token.RefreshToken != "" && now.Before(tm.refreshExpiresAt) {
+		tm.logger.Info("Refreshing token", "jwt", "GetToken")
+		token, err = tm.requester(ctx, tm.token.RefreshToken)
+		if err != nil {
+			tm.logger.Warning("Token refresh failed, requesting a new token", "jwt", "GetToken")
+		}
+	}
+
+	if token == nil {
+		tm.logger.Info("Requesting new token", "jwt", "GetToken")
+		token, err = tm.requester(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if token == nil || token.AccessToken == "" {
+		return nil, errors.New("empty token received")
+	}
+
+	if tm.verifier != nil {
+		err = tm.verifier.Verify(ctx, token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tm.setToken(token, now)
+	return tm.token, nil
+}
+
+// SetVerifier sets the verifier used to check the signature, issuer and audience of every token received
+//
+// Parameters:
+//   - verifier: Verifier of the tokens
+//
+// Returns:
+func (tm *TokenManager) SetVerifier(verifier *JWKSVerifier) {
+	tm.mutex.Lock()
+	tm.verifier = verifier
+	tm.mutex.Unlock()
+}
+
+// Invalidate discards the current token, the next call to GetToken requests a new token
+//
+// Parameters:
+//
+// Returns:
+func (tm *TokenManager) Invalidate() {
+	tm.mutex.Lock()
+	tm.token = nil
+	tm.mutex.Unlock()
+}
+
+// setToken stores a new token and calculates when it must be refreshed
+//
+// Parameters:
+//   - token: Token received
+//   - obtainedAt: Time when the token was requested
+//
+// Returns:
+func (tm *TokenManager) setToken(token *JWKToken, obtainedAt time.Time) {
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		// Use the exp claim when the server does not inform expires_in
+		expiration, ok := getExpirationTime(token.AccessToken)
+		if ok {
+			lifetime = expiration.Sub(obtainedAt)
+		}
+	}
+
+	tm.token = token
+	tm.refreshAt = obtainedAt.Add(time.Duration(float64(lifetime) * tm.refreshFraction))
+	if latest := obtainedAt.Add(lifetime - tm.clockSkew); latest.Before(tm.refreshAt) {
+		tm.refreshAt = latest
+	}
+
+	tm.refreshExpiresAt = time.Time{}
+	if token.RefreshToken != "" && token.RefreshExpiresIn > 0 {
+		tm.refreshExpiresAt = obtainedAt.Add(time.Duration(token.RefreshExpiresIn)*time.Second - tm.clockSkew)
+	}
+
+	tm.logger.Debug("Token will be refreshed at: "+tm.refreshAt.String(), "jwt", "setToken")
+}
+
+// getExpirationTime reads the exp claim of a token without verifying it
+//
+// Parameters:
+//   - accessToken: Token to be read
+//
+// Returns:
+//   - time.Time: Expiration time of the token
+//   - bool: false if the token or the claim are not valid
+func getExpirationTime(accessToken string) (time.Time, bool) {
+	parsedToken, _, err := jwt.NewParser().ParseUnverified(accessToken, jwt.MapClaims{})
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	expiration, err := parsedToken.Claims.GetExpirationTime()
+	if err != nil || expiration == nil {
+		return time.Time{}, false
+	}
+
+	return expiration.Time, true
+}
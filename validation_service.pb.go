@@ -0,0 +1,374 @@
+// gRPC ingestion API of the MQD Client, the service is served on the GRPCPort configured in settings.yml
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: validation_service.proto
+
+package ingestionpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ValidateResponseRequest contains a response to be validated, with the same values sent as headers on /ValidateResponse
+type ValidateResponseRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ServerOrgId        string                 `protobuf:"bytes,1,opt,name=server_org_id,json=serverOrgId,proto3" json:"server_org_id,omitempty"`
+	XFapiInteractionId string                 `protobuf:"bytes,2,opt,name=x_fapi_interaction_id,json=xFapiInteractionId,proto3" json:"x_fapi_interaction_id,omitempty"`
+	EndpointName       string                 `protobuf:"bytes,3,opt,name=endpoint_name,json=endpointName,proto3" json:"endpoint_name,omitempty"`
+	Version            string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	ConsentId          string                 `protobuf:"bytes,5,opt,name=consent_id,json=consentId,proto3" json:"consent_id,omitempty"`
+	TransmitterId      string                 `protobuf:"bytes,6,opt,name=transmitter_id,json=transmitterId,proto3" json:"transmitter_id,omitempty"`
+	ResponseHeaders    map[string]string      `protobuf:"bytes,7,rep,name=response_headers,json=responseHeaders,proto3" json:"response_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Body               string                 `protobuf:"bytes,8,opt,name=body,proto3" json:"body,omitempty"`
+	// Path and HTTP method of the original request, the endpoint is resolved with them if endpoint_name is empty
+	RequestPath   string `protobuf:"bytes,9,opt,name=request_path,json=requestPath,proto3" json:"request_path,omitempty"`
+	HttpMethod    string `protobuf:"bytes,10,opt,name=http_method,json=httpMethod,proto3" json:"http_method,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateResponseRequest) Reset() {
+	*x = ValidateResponseRequest{}
+	mi := &file_validation_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateResponseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateResponseRequest) ProtoMessage() {}
+
+func (x *ValidateResponseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_validation_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateResponseRequest.ProtoReflect.Descriptor instead.
+func (*ValidateResponseRequest) Descriptor() ([]byte, []int) {
+	return file_validation_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ValidateResponseRequest) GetServerOrgId() string {
+	if x != nil {
+		return x.ServerOrgId
+	}
+	return ""
+}
+
+func (x *ValidateResponseRequest) GetXFapiInteractionId() string {
+	if x != nil {
+		return x.XFapiInteractionId
+	}
+	return ""
+}
+
+func (x *ValidateResponseRequest) GetEndpointName() string {
+	if x != nil {
+		return x.EndpointName
+	}
+	return ""
+}
+
+func (x *ValidateResponseRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ValidateResponseRequest) GetConsentId() string {
+	if x != nil {
+		return x.ConsentId
+	}
+	return ""
+}
+
+func (x *ValidateResponseRequest) GetTransmitterId() string {
+	if x != nil {
+		return x.TransmitterId
+	}
+	return ""
+}
+
+func (x *ValidateResponseRequest) GetResponseHeaders() map[string]string {
+	if x != nil {
+		return x.ResponseHeaders
+	}
+	return nil
+}
+
+func (x *ValidateResponseRequest) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *ValidateResponseRequest) GetRequestPath() string {
+	if x != nil {
+		return x.RequestPath
+	}
+	return ""
+}
+
+func (x *ValidateResponseRequest) GetHttpMethod() string {
+	if x != nil {
+		return x.HttpMethod
+	}
+	return ""
+}
+
+// ValidateResponseReply contains the result of a message
+type ValidateResponseReply struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Index              int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	XFapiInteractionId string                 `protobuf:"bytes,2,opt,name=x_fapi_interaction_id,json=xFapiInteractionId,proto3" json:"x_fapi_interaction_id,omitempty"`
+	Accepted           bool                   `protobuf:"varint,3,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Message            string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	RetryAfter         int32                  `protobuf:"varint,5,opt,name=retry_after,json=retryAfter,proto3" json:"retry_after,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ValidateResponseReply) Reset() {
+	*x = ValidateResponseReply{}
+	mi := &file_validation_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateResponseReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateResponseReply) ProtoMessage() {}
+
+func (x *ValidateResponseReply) ProtoReflect() protoreflect.Message {
+	mi := &file_validation_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateResponseReply.ProtoReflect.Descriptor instead.
+func (*ValidateResponseReply) Descriptor() ([]byte, []int) {
+	return file_validation_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ValidateResponseReply) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ValidateResponseReply) GetXFapiInteractionId() string {
+	if x != nil {
+		return x.XFapiInteractionId
+	}
+	return ""
+}
+
+func (x *ValidateResponseReply) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *ValidateResponseReply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ValidateResponseReply) GetRetryAfter() int32 {
+	if x != nil {
+		return x.RetryAfter
+	}
+	return 0
+}
+
+// ValidateResponsesReply contains the results of a stream of messages
+type ValidateResponsesReply struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Accepted      int32                    `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected      int32                    `protobuf:"varint,2,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	Results       []*ValidateResponseReply `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateResponsesReply) Reset() {
+	*x = ValidateResponsesReply{}
+	mi := &file_validation_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateResponsesReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateResponsesReply) ProtoMessage() {}
+
+func (x *ValidateResponsesReply) ProtoReflect() protoreflect.Message {
+	mi := &file_validation_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateResponsesReply.ProtoReflect.Descriptor instead.
+func (*ValidateResponsesReply) Descriptor() ([]byte, []int) {
+	return file_validation_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ValidateResponsesReply) GetAccepted() int32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *ValidateResponsesReply) GetRejected() int32 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *ValidateResponsesReply) GetResults() []*ValidateResponseReply {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+var File_validation_service_proto protoreflect.FileDescriptor
+
+const file_validation_service_proto_rawDesc = "" +
+	"\n" +
+	"\x18validation_service.proto\x12\x10mqd.ingestion.v1\"\xfc\x03\n" +
+	"\x17ValidateResponseRequest\x12\"\n" +
+	"\rserver_org_id\x18\x01 \x01(\tR\vserverOrgId\x121\n" +
+	"\x15x_fapi_interaction_id\x18\x02 \x01(\tR\x12xFapiInteractionId\x12#\n" +
+	"\rendpoint_name\x18\x03 \x01(\tR\fendpointName\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\x12\x1d\n" +
+	"\n" +
+	"consent_id\x18\x05 \x01(\tR\tconsentId\x12%\n" +
+	"\x0etransmitter_id\x18\x06 \x01(\tR\rtransmitterId\x12i\n" +
+	"\x10response_headers\x18\a \x03(\v2>.mqd.ingestion.v1.ValidateResponseRequest.ResponseHeadersEntryR\x0fresponseHeaders\x12\x12\n" +
+	"\x04body\x18\b \x01(\tR\x04body\x12!\n" +
+	"\frequest_path\x18\t \x01(\tR\vrequestPath\x12\x1f\n" +
+	"\vhttp_method\x18\n" +
+	" \x01(\tR\n" +
+	"httpMethod\x1aB\n" +
+	"\x14ResponseHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb7\x01\n" +
+	"\x15ValidateResponseReply\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x121\n" +
+	"\x15x_fapi_interaction_id\x18\x02 \x01(\tR\x12xFapiInteractionId\x12\x1a\n" +
+	"\baccepted\x18\x03 \x01(\bR\baccepted\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x1f\n" +
+	"\vretry_after\x18\x05 \x01(\x05R\n" +
+	"retryAfter\"\x93\x01\n" +
+	"\x16ValidateResponsesReply\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x05R\baccepted\x12\x1a\n" +
+	"\brejected\x18\x02 \x01(\x05R\brejected\x12A\n" +
+	"\aresults\x18\x03 \x03(\v2'.mqd.ingestion.v1.ValidateResponseReplyR\aresults2\xe7\x01\n" +
+	"\x11ValidationService\x12f\n" +
+	"\x10ValidateResponse\x12).mqd.ingestion.v1.ValidateResponseRequest\x1a'.mqd.ingestion.v1.ValidateResponseReply\x12j\n" +
+	"\x11ValidateResponses\x12).mqd.ingestion.v1.ValidateResponseRequest\x1a(.mqd.ingestion.v1.ValidateResponsesReply(\x01BBZ@github.com/OpenBanking-Brasil/MQD_Client/application/ingestionpbb\x06proto3"
+
+var (
+	file_validation_service_proto_rawDescOnce sync.Once
+	file_validation_service_proto_rawDescData []byte
+)
+
+func file_validation_service_proto_rawDescGZIP() []byte {
+	file_validation_service_proto_rawDescOnce.Do(func() {
+		file_validation_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_validation_service_proto_rawDesc), len(file_validation_service_proto_rawDesc)))
+	})
+	return file_validation_service_proto_rawDescData
+}
+
+var file_validation_service_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_validation_service_proto_goTypes = []any{
+	(*ValidateResponseRequest)(nil), // 0: mqd.ingestion.v1.ValidateResponseRequest
+	(*ValidateResponseReply)(nil),   // 1: mqd.ingestion.v1.ValidateResponseReply
+	(*ValidateResponsesReply)(nil),  // 2: mqd.ingestion.v1.ValidateResponsesReply
+	nil,                             // 3: mqd.ingestion.v1.ValidateResponseRequest.ResponseHeadersEntry
+}
+var file_validation_service_proto_depIdxs = []int32{
+	3, // 0: mqd.ingestion.v1.ValidateResponseRequest.response_headers:type_name -> mqd.ingestion.v1.ValidateResponseRequest.ResponseHeadersEntry
+	1, // 1: mqd.ingestion.v1.ValidateResponsesReply.results:type_name -> mqd.ingestion.v1.ValidateResponseReply
+	0, // 2: mqd.ingestion.v1.ValidationService.ValidateResponse:input_type -> mqd.ingestion.v1.ValidateResponseRequest
+	0, // 3: mqd.ingestion.v1.ValidationService.ValidateResponses:input_type -> mqd.ingestion.v1.ValidateResponseRequest
+	1, // 4: mqd.ingestion.v1.ValidationService.ValidateResponse:output_type -> mqd.ingestion.v1.ValidateResponseReply
+	2, // 5: mqd.ingestion.v1.ValidationService.ValidateResponses:output_type -> mqd.ingestion.v1.ValidateResponsesReply
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_validation_service_proto_init() }
+func file_validation_service_proto_init() {
+	if File_validation_service_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_validation_service_proto_rawDesc), len(file_validation_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_validation_service_proto_goTypes,
+		DependencyIndexes: file_validation_service_proto_depIdxs,
+		MessageInfos:      file_validation_service_proto_msgTypes,
+	}.Build()
+	File_validation_service_proto = out.File
+	file_validation_service_proto_goTypes = nil
+	file_validation_service_proto_depIdxs = nil
+}
@@ -0,0 +1,163 @@
+// gRPC ingestion API of the MQD Client, the service is served on the GRPCPort configured in settings.yml
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: validation_service.proto
+
+package ingestionpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ValidationService_ValidateResponse_FullMethodName  = "/mqd.ingestion.v1.ValidationService/ValidateResponse"
+	ValidationService_ValidateResponses_FullMethodName = "/mqd.ingestion.v1.ValidationService/ValidateResponses"
+)
+
+// ValidationServiceClient is the client API for ValidationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ValidationService receives the responses to be validated, the same way as the /ValidateResponse HTTP endpoint
+type ValidationServiceClient interface {
+	// ValidateResponse enqueues a single response for validation
+	ValidateResponse(ctx context.Context, in *ValidateResponseRequest, opts ...grpc.CallOption) (*ValidateResponseReply, error)
+	// ValidateResponses enqueues a stream of responses and returns the result of every message once the stream is closed
+	ValidateResponses(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ValidateResponseRequest, ValidateResponsesReply], error)
+}
+
+type validationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewValidationServiceClient(cc grpc.ClientConnInterface) ValidationServiceClient {
+	return &validationServiceClient{cc}
+}
+
+func (c *validationServiceClient) ValidateResponse(ctx context.Context, in *ValidateResponseRequest, opts ...grpc.CallOption) (*ValidateResponseReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateResponseReply)
+	err := c.cc.Invoke(ctx, ValidationService_ValidateResponse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *validationServiceClient) ValidateResponses(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ValidateResponseRequest, ValidateResponsesReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ValidationService_ServiceDesc.Streams[0], ValidationService_ValidateResponses_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ValidateResponseRequest, ValidateResponsesReply]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ValidationService_ValidateResponsesClient = grpc.ClientStreamingClient[ValidateResponseRequest, ValidateResponsesReply]
+
+// ValidationServiceServer is the server API for ValidationService service.
+// All implementations must embed UnimplementedValidationServiceServer
+// for forward compatibility.
+//
+// ValidationService receives the responses to be validated, the same way as the /ValidateResponse HTTP endpoint
+type ValidationServiceServer interface {
+	// ValidateResponse enqueues a single response for validation
+	ValidateResponse(context.Context, *ValidateResponseRequest) (*ValidateResponseReply, error)
+	// ValidateResponses enqueues a stream of responses and returns the result of every message once the stream is closed
+	ValidateResponses(grpc.ClientStreamingServer[ValidateResponseRequest, ValidateResponsesReply]) error
+	mustEmbedUnimplementedValidationServiceServer()
+}
+
+// UnimplementedValidationServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedValidationServiceServer struct{}
+
+func (UnimplementedValidationServiceServer) ValidateResponse(context.Context, *ValidateResponseRequest) (*ValidateResponseReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateResponse not implemented")
+}
+func (UnimplementedValidationServiceServer) ValidateResponses(grpc.ClientStreamingServer[ValidateResponseRequest, ValidateResponsesReply]) error {
+	return status.Errorf(codes.Unimplemented, "method ValidateResponses not implemented")
+}
+func (UnimplementedValidationServiceServer) mustEmbedUnimplementedValidationServiceServer() {}
+func (UnimplementedValidationServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeValidationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ValidationServiceServer will
+// result in compilation errors.
+type UnsafeValidationServiceServer interface {
+	mustEmbedUnimplementedValidationServiceServer()
+}
+
+func RegisterValidationServiceServer(s grpc.ServiceRegistrar, srv ValidationServiceServer) {
+	// If the following call pancis, it indicates UnimplementedValidationServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ValidationService_ServiceDesc, srv)
+}
+
+func _ValidationService_ValidateResponse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateResponseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ValidationServiceServer).ValidateResponse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ValidationService_ValidateResponse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ValidationServiceServer).ValidateResponse(ctx, req.(*ValidateResponseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ValidationService_ValidateResponses_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ValidationServiceServer).ValidateResponses(&grpc.GenericServerStream[ValidateResponseRequest, ValidateResponsesReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ValidationService_ValidateResponsesServer = grpc.ClientStreamingServer[ValidateResponseRequest, ValidateResponsesReply]
+
+// ValidationService_ServiceDesc is the grpc.ServiceDesc for ValidationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ValidationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mqd.ingestion.v1.ValidationService",
+	HandlerType: (*ValidationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ValidateResponse",
+			Handler:    _ValidationService_ValidateResponse_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ValidateResponses",
+			Handler:       _ValidationService_ValidateResponses_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "validation_service.proto",
+}